@@ -0,0 +1,223 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// the golab config file either holds a single target as top-level url and
+// token or several named environments, each with its own url and token:
+//
+//	---
+//	current_env: localhost
+//	gitlab.com:
+//	  url: "https://gitlab.com"
+//	  token: "gitlab_com_token"
+//	localhost:
+//	  url: "http://localhost:12345"
+//	  token: "localhost_token"
+const currentEnvKey = "current_env"
+
+var reservedConfigKeys = []string{"url", "token", currentEnvKey}
+
+type environment struct {
+	Name    string `json:"name"`
+	Url     string `json:"url"`
+	Token   string `json:"-"`
+	Current bool   `json:"current"`
+}
+
+type golabConfig struct {
+	filename string
+	content  yaml.MapSlice
+}
+
+// golabConfigFile returns the config file viper read the configuration from,
+// or .golab.yml in the current directory if no config file was found.
+func golabConfigFile() (string, error) {
+	if filename := viper.ConfigFileUsed(); filename != "" {
+		return filename, nil
+	}
+	pwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(pwd, ".golab.yml"), nil
+}
+
+func readGolabConfig(filename string) (*golabConfig, error) {
+	conf := &golabConfig{filename: filename}
+	content, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return conf, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(content, &conf.content); err != nil {
+		return nil, fmt.Errorf("could not parse golab config %s: %s", filename, err)
+	}
+	return conf, nil
+}
+
+func (c *golabConfig) write() error {
+	content, err := yaml.Marshal(c.content)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.filename, append([]byte("---\n"), content...), 0600)
+}
+
+func (c *golabConfig) get(key string) (interface{}, bool) {
+	for _, item := range c.content {
+		if k, ok := item.Key.(string); ok && k == key {
+			return item.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (c *golabConfig) set(key string, value interface{}) {
+	for i, item := range c.content {
+		if k, ok := item.Key.(string); ok && k == key {
+			c.content[i].Value = value
+			return
+		}
+	}
+	c.content = append(c.content, yaml.MapItem{Key: key, Value: value})
+}
+
+func (c *golabConfig) remove(key string) bool {
+	for i, item := range c.content {
+		if k, ok := item.Key.(string); ok && k == key {
+			c.content = append(c.content[:i], c.content[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (c *golabConfig) currentEnvironment() string {
+	if current, ok := c.get(currentEnvKey); ok {
+		return fmt.Sprint(current)
+	}
+	return ""
+}
+
+func (c *golabConfig) setCurrentEnvironment(name string) error {
+	if _, ok := c.environment(name); !ok {
+		return fmt.Errorf("environment '%s' is not configured in %s", name, c.filename)
+	}
+	c.set(currentEnvKey, name)
+	return nil
+}
+
+// environments returns all top-level entries of the config that have a url
+func (c *golabConfig) environments() []environment {
+	var envs []environment
+	current := c.currentEnvironment()
+	for _, item := range c.content {
+		name, ok := item.Key.(string)
+		if !ok || isReservedConfigKey(name) {
+			continue
+		}
+		if env, ok := toEnvironment(name, item.Value); ok {
+			env.Current = name == current
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
+
+func (c *golabConfig) environment(name string) (environment, bool) {
+	for _, env := range c.environments() {
+		if env.Name == name {
+			return env, true
+		}
+	}
+	return environment{}, false
+}
+
+func (c *golabConfig) setEnvironment(name string, url string, token string) error {
+	if err := validateEnvironmentName(name); err != nil {
+		return err
+	}
+	c.set(name, yaml.MapSlice{
+		{Key: "url", Value: url},
+		{Key: "token", Value: token},
+	})
+	return nil
+}
+
+func (c *golabConfig) removeEnvironment(name string) error {
+	if _, ok := c.environment(name); !ok {
+		return fmt.Errorf("environment '%s' is not configured in %s", name, c.filename)
+	}
+	c.remove(name)
+	if c.currentEnvironment() == name {
+		c.remove(currentEnvKey)
+	}
+	return nil
+}
+
+func toEnvironment(name string, value interface{}) (environment, bool) {
+	entries, ok := value.(yaml.MapSlice)
+	if !ok {
+		return environment{}, false
+	}
+	env := environment{Name: name}
+	for _, entry := range entries {
+		switch entry.Key {
+		case "url":
+			env.Url = fmt.Sprint(entry.Value)
+		case "token":
+			env.Token = fmt.Sprint(entry.Value)
+		}
+	}
+	return env, env.Url != ""
+}
+
+func validateEnvironmentName(name string) error {
+	if name == "" {
+		return errors.New("environment name must not be empty")
+	}
+	if isReservedConfigKey(name) {
+		return fmt.Errorf("'%s' cannot be used as environment name", name)
+	}
+	return nil
+}
+
+func isReservedConfigKey(key string) bool {
+	for _, reserved := range reservedConfigKeys {
+		if key == reserved {
+			return true
+		}
+	}
+	return false
+}
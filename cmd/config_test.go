@@ -0,0 +1,111 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("golab config", func() {
+
+	var (
+		dir      string
+		filename string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "golab-config")
+		Expect(err).To(BeNil())
+		filename = path.Join(dir, ".golab.yml")
+		content := `---
+url: "http://legacy.localhost"
+token: "legacy_token"
+gitlab.com:
+  url: "https://gitlab.com"
+  token: "gitlab_com_token"
+localhost:
+  url: "http://localhost:12345"
+  token: "localhost_token"
+`
+		Expect(ioutil.WriteFile(filename, []byte(content), 0600)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("returns all configured environments", func() {
+		conf, err := readGolabConfig(filename)
+		Expect(err).To(BeNil())
+		Expect(conf.environments()).To(Equal([]environment{
+			{Name: "gitlab.com", Url: "https://gitlab.com", Token: "gitlab_com_token"},
+			{Name: "localhost", Url: "http://localhost:12345", Token: "localhost_token"},
+		}))
+	})
+
+	It("adds an environment without touching the other entries", func() {
+		conf, err := readGolabConfig(filename)
+		Expect(err).To(BeNil())
+		Expect(conf.setEnvironment("docker", "http://docker:8080", "docker_token")).To(Succeed())
+		Expect(conf.setEnvironment("localhost", "http://localhost:8080", "new_token")).To(Succeed())
+		Expect(conf.write()).To(Succeed())
+
+		conf, err = readGolabConfig(filename)
+		Expect(err).To(BeNil())
+		Expect(conf.environments()).To(HaveLen(3))
+		env, ok := conf.environment("localhost")
+		Expect(ok).To(BeTrue())
+		Expect(env.Url).To(Equal("http://localhost:8080"))
+		Expect(env.Token).To(Equal("new_token"))
+		url, _ := conf.get("url")
+		Expect(url).To(Equal("http://legacy.localhost"))
+	})
+
+	It("sets and resets the current environment", func() {
+		conf, err := readGolabConfig(filename)
+		Expect(err).To(BeNil())
+		Expect(conf.setCurrentEnvironment("unknown")).NotTo(Succeed())
+		Expect(conf.setCurrentEnvironment("localhost")).To(Succeed())
+		Expect(selectedEnvironment(conf)).To(Equal("localhost"))
+		Expect(conf.removeEnvironment("localhost")).To(Succeed())
+		Expect(conf.currentEnvironment()).To(Equal(""))
+		Expect(conf.environments()).To(HaveLen(1))
+	})
+
+	It("rejects reserved keys as environment names", func() {
+		conf, err := readGolabConfig(filename)
+		Expect(err).To(BeNil())
+		Expect(conf.setEnvironment("url", "http://localhost", "token")).NotTo(Succeed())
+		Expect(conf.setEnvironment(currentEnvKey, "http://localhost", "token")).NotTo(Succeed())
+	})
+
+	It("returns an empty config if the config file does not exist", func() {
+		conf, err := readGolabConfig(path.Join(dir, "missing.yml"))
+		Expect(err).To(BeNil())
+		Expect(conf.environments()).To(BeEmpty())
+	})
+})
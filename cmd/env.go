@@ -0,0 +1,129 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+)
+
+var envCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:   "env",
+		Short: "Manage environments",
+		Long: `Manage the Gitlab environments (servers) stored in the golab config file.
+
+New environments are added with 'golab login --env <name>'. Each command can be run against a certain environment by providing --env <name> or by setting $GOLAB_ENV.`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot run this command without further sub-commands")
+	},
+}
+
+var envListCmd = &golabCommand{
+	Parent: envCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List environments",
+		Long:  `List all environments configured in the golab config file`,
+	},
+	Run: func(cmd golabCommand) error {
+		conf, err := currentGolabConfig()
+		if err != nil {
+			return err
+		}
+		envs := conf.environments()
+		selected := selectedEnvironment(conf)
+		for i := range envs {
+			envs[i].Current = envs[i].Name == selected
+		}
+		return OutputJson(envs)
+	},
+}
+
+var envUseCmd = &golabCommand{
+	Parent: envCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "use <name>",
+		Short: "Set current environment",
+		Long:  `Set the environment that is used if neither --env nor $GOLAB_ENV are given`,
+		Args:  cobra.ExactArgs(1),
+	},
+	Run: func(cmd golabCommand) error {
+		conf, err := currentGolabConfig()
+		if err != nil {
+			return err
+		}
+		if err := conf.setCurrentEnvironment(cmd.Args[0]); err != nil {
+			return err
+		}
+		if err := conf.write(); err != nil {
+			return err
+		}
+		fmt.Printf("** switched to environment %s\n", cmd.Args[0])
+		return nil
+	},
+}
+
+var envRemoveCmd = &golabCommand{
+	Parent: envCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "rm <name>",
+		Short: "Remove environment",
+		Long:  `Remove an environment from the golab config file`,
+		Args:  cobra.ExactArgs(1),
+	},
+	Run: func(cmd golabCommand) error {
+		conf, err := currentGolabConfig()
+		if err != nil {
+			return err
+		}
+		if err := conf.removeEnvironment(cmd.Args[0]); err != nil {
+			return err
+		}
+		if err := conf.write(); err != nil {
+			return err
+		}
+		fmt.Printf("** removed environment %s\n", cmd.Args[0])
+		return nil
+	},
+}
+
+func currentGolabConfig() (*golabConfig, error) {
+	filename, err := golabConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	return readGolabConfig(filename)
+}
+
+func init() {
+	AddDefaultColumns(environment{}, "name", "url", "current")
+	envCmd.Init()
+	envListCmd.Init()
+	envUseCmd.Init()
+	envRemoveCmd.Init()
+}
@@ -0,0 +1,384 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// deployEnvironment extends the vendored Environment with its state and last
+// deployment
+type deployEnvironment struct {
+	ID             int         `json:"id"`
+	Name           string      `json:"name"`
+	Slug           string      `json:"slug"`
+	ExternalURL    string      `json:"external_url"`
+	State          string      `json:"state"`
+	CreatedAt      *time.Time  `json:"created_at"`
+	UpdatedAt      *time.Time  `json:"updated_at"`
+	LastDeployment *deployment `json:"last_deployment"`
+}
+
+// see https://docs.gitlab.com/ce/api/deployments.html
+type deployment struct {
+	ID          int                `json:"id"`
+	IID         int                `json:"iid"`
+	Ref         string             `json:"ref"`
+	SHA         string             `json:"sha"`
+	Status      string             `json:"status"`
+	CreatedAt   *time.Time         `json:"created_at"`
+	UpdatedAt   *time.Time         `json:"updated_at"`
+	User        *gitlab.User       `json:"user"`
+	Environment *deployEnvironment `json:"environment"`
+	Deployable  *deploymentJob     `json:"deployable"`
+}
+
+type deploymentJob struct {
+	ID     int            `json:"id"`
+	Name   string         `json:"name"`
+	Stage  string         `json:"stage"`
+	Status string         `json:"status"`
+	Commit *gitlab.Commit `json:"commit"`
+}
+
+// see https://docs.gitlab.com/ce/api/environments.html
+var environmentsCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "environments",
+		Aliases: []string{"environment"},
+		Short:   "Manage environments",
+		Long:    `List, create, edit, stop and delete the environments of a project and show their deployments`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/environments.html#list-environments
+type environmentsListFlags struct {
+	Id *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+}
+
+var environmentsListCmd = &golabCommand{
+	Parent: environmentsCmd.Cmd,
+	Flags:  &environmentsListFlags{},
+	Opts:   &gitlab.ListEnvironmentsOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List environments",
+		Long:  `Get all environments for a given project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*environmentsListFlags)
+		opts := cmd.Opts.(*gitlab.ListEnvironmentsOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			var environments []*deployEnvironment
+			resp, err := gitlabRequest("GET", projectPath(*flags.Id, "/environments"), opts, &environments)
+			return environments, resp, err
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/environments.html#create-a-new-environment
+type environmentsCreateFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Name        *string `flag_name:"name" short:"n" type:"string" required:"yes" description:"The name of the environment"`
+	ExternalURL *string `flag_name:"external_url" short:"u" type:"string" required:"no" description:"Place to link to for this environment"`
+}
+
+var environmentsCreateCmd = &golabCommand{
+	Parent: environmentsCmd.Cmd,
+	Flags:  &environmentsCreateFlags{},
+	Opts:   &gitlab.CreateEnvironmentOptions{},
+	Cmd: &cobra.Command{
+		Use:   "create",
+		Short: "Create a new environment",
+		Long:  `Creates a new environment with the given name and external_url.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*environmentsCreateFlags)
+		opts := cmd.Opts.(*gitlab.CreateEnvironmentOptions)
+		env, _, err := gitlabClient.Environments.CreateEnvironment(*flags.Id, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(env)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/environments.html#edit-an-existing-environment
+type environmentsEditFlags struct {
+	Id            *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	EnvironmentId *int    `flag_name:"environment_id" short:"v" type:"integer" required:"yes" description:"The ID of the environment"`
+	Name          *string `flag_name:"name" short:"n" type:"string" required:"no" description:"The new name of the environment"`
+	ExternalURL   *string `flag_name:"external_url" short:"u" type:"string" required:"no" description:"The new external_url"`
+}
+
+var environmentsEditCmd = &golabCommand{
+	Parent: environmentsCmd.Cmd,
+	Flags:  &environmentsEditFlags{},
+	Opts:   &gitlab.EditEnvironmentOptions{},
+	Cmd: &cobra.Command{
+		Use:   "edit",
+		Short: "Edit an existing environment",
+		Long:  `Updates an existing environment's name and/or external_url.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*environmentsEditFlags)
+		opts := cmd.Opts.(*gitlab.EditEnvironmentOptions)
+		env, _, err := gitlabClient.Environments.EditEnvironment(*flags.Id, *flags.EnvironmentId, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(env)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/environments.html#delete-an-environment
+type environmentsDeleteFlags struct {
+	Id            *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	EnvironmentId *int    `flag_name:"environment_id" short:"v" type:"integer" required:"yes" description:"The ID of the environment"`
+}
+
+var environmentsDeleteCmd = &golabCommand{
+	Parent: environmentsCmd.Cmd,
+	Flags:  &environmentsDeleteFlags{},
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Delete an environment",
+		Long:  `Deletes an environment. Only stopped environments can be deleted.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*environmentsDeleteFlags)
+		_, err := gitlabClient.Environments.DeleteEnvironment(*flags.Id, *flags.EnvironmentId)
+		return err
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/environments.html#stop-an-environment
+type environmentsStopFlags struct {
+	Id            *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	EnvironmentId *int    `flag_name:"environment_id" short:"v" type:"integer" required:"yes" description:"The ID of the environment"`
+}
+
+var environmentsStopCmd = &golabCommand{
+	Parent: environmentsCmd.Cmd,
+	Flags:  &environmentsStopFlags{},
+	Cmd: &cobra.Command{
+		Use:   "stop",
+		Short: "Stop an environment",
+		Long:  `Stops an environment, this runs the on_stop action of the environment if there is one.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*environmentsStopFlags)
+		env := &deployEnvironment{}
+		_, err := gitlabRequest("POST", projectPath(*flags.Id, "/environments/%d/stop", *flags.EnvironmentId), nil, env)
+		if err != nil {
+			return err
+		}
+		return OutputJson(env)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/deployments.html#list-project-deployments
+type environmentsDeploymentsFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Environment *string `flag_name:"environment" short:"n" type:"string" required:"no" description:"The name of the environment, if not given as argument"`
+}
+
+type listDeploymentsOptions struct {
+	gitlab.ListOptions
+	Environment *string `url:"environment,omitempty" json:"environment,omitempty"`
+	OrderBy     *string `url:"order_by,omitempty" json:"order_by,omitempty"`
+	Sort        *string `url:"sort,omitempty" json:"sort,omitempty"`
+}
+
+var environmentsDeploymentsCmd = &golabCommand{
+	Parent: environmentsCmd.Cmd,
+	Flags:  &environmentsDeploymentsFlags{},
+	Opts:   &listDeploymentsOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "deployments <environment>",
+		Short: "List deployments of an environment",
+		Long: `List the deployments of an environment, most recent first, with the deployed ref, commit and user.
+
+Example:
+
+    golab environments deployments -i my-group/my-project production`,
+		Args: cobra.MaximumNArgs(1),
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*environmentsDeploymentsFlags)
+		opts := cmd.Opts.(*listDeploymentsOptions)
+		switch {
+		case len(cmd.Args) == 1 && flags.Environment != nil:
+			return errors.New("the environment can either be given as argument or with --environment")
+		case len(cmd.Args) == 1:
+			opts.Environment = &cmd.Args[0]
+		case flags.Environment == nil:
+			return errors.New("the name of the environment is required")
+		}
+		opts.OrderBy, opts.Sort = gitlab.String("created_at"), gitlab.String("desc")
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			var deployments []*deployment
+			resp, err := gitlabRequest("GET", projectPath(*flags.Id, "/deployments"), opts, &deployments)
+			return deployments, resp, err
+		})
+	},
+}
+
+type environmentsCleanupFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	NamePattern *string `flag_name:"name_pattern" short:"n" type:"string" required:"yes" description:"Shell pattern the environment names have to match, e.g. 'review/*'"`
+	OlderThan   *string `flag_name:"older_than" short:"t" type:"string" required:"no" description:"Only environments without deployment for the given duration, e.g. 14d, 2w or 36h"`
+	All         *bool   `flag_name:"all" type:"boolean" required:"no" description:"Stop and delete all environments matching the pattern, no matter when they were deployed"`
+	DryRun      *bool   `flag_name:"dry_run" type:"boolean" required:"no" description:"Only print the environments that would be stopped and deleted"`
+}
+
+var environmentsCleanupCmd = &golabCommand{
+	Parent: environmentsCmd.Cmd,
+	Flags:  &environmentsCleanupFlags{},
+	Cmd: &cobra.Command{
+		Use:   "cleanup",
+		Short: "Stop and delete stale environments",
+		Long: `Stop and delete all environments whose name matches a pattern and that were not deployed for a given time.
+
+The age of an environment is the time since its last deployment, environments that were never deployed are always stale. Either --older_than or --all has to be given, so that active environments like production are not deleted by accident.
+
+Example:
+
+    golab environments cleanup -i my-group/my-project -n 'review/*' -t 14d --dry_run`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*environmentsCleanupFlags)
+		if _, err := path.Match(*flags.NamePattern, ""); err != nil {
+			return fmt.Errorf("invalid name pattern %s: %s", *flags.NamePattern, err)
+		}
+		all := flags.All != nil && *flags.All
+		if flags.OlderThan == nil && !all {
+			return errors.New("either --older_than or --all is required")
+		}
+		if flags.OlderThan != nil && all {
+			return errors.New("--older_than cannot be used together with --all")
+		}
+		var olderThan time.Duration
+		if flags.OlderThan != nil {
+			age, err := parseAge(*flags.OlderThan)
+			if err != nil {
+				return err
+			}
+			olderThan = age
+		}
+		return cleanupEnvironments(*flags.Id, *flags.NamePattern, olderThan, flags.DryRun != nil && *flags.DryRun)
+	},
+}
+
+// cleanupEnvironments stops and deletes the environments whose name matches
+// pattern and that were not deployed within olderThan
+func cleanupEnvironments(pid string, pattern string, olderThan time.Duration, dryRun bool) error {
+	var environments []*deployEnvironment
+	opts := &gitlab.ListEnvironmentsOptions{}
+	err := forEachPage(&opts.ListOptions, func() (*gitlab.Response, error) {
+		var page []*deployEnvironment
+		resp, err := gitlabRequest("GET", projectPath(pid, "/environments"), opts, &page)
+		environments = append(environments, page...)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	for _, env := range environments {
+		if matches, _ := path.Match(pattern, env.Name); !matches {
+			continue
+		}
+		// the last deployment is only returned for single environments
+		if _, err := gitlabRequest("GET", projectPath(pid, "/environments/%d", env.ID), nil, env); err != nil {
+			return err
+		}
+		lastDeployed := "never deployed"
+		if env.LastDeployment != nil && env.LastDeployment.CreatedAt != nil {
+			if olderThan > 0 && time.Since(*env.LastDeployment.CreatedAt) < olderThan {
+				continue
+			}
+			lastDeployed = "last deployed " + env.LastDeployment.CreatedAt.Format(time.RFC3339)
+		}
+		if dryRun {
+			fmt.Printf("would delete %s (%s)\n", env.Name, lastDeployed)
+			continue
+		}
+		if env.State != "stopped" {
+			if _, err := gitlabRequest("POST", projectPath(pid, "/environments/%d/stop", env.ID), nil, nil); err != nil {
+				return err
+			}
+		}
+		if _, err := gitlabClient.Environments.DeleteEnvironment(pid, env.ID); err != nil {
+			return err
+		}
+		fmt.Printf("deleted %s (%s)\n", env.Name, lastDeployed)
+	}
+	return nil
+}
+
+// parseAge parses durations like 36h or 90m and additionally supports days
+// (14d) and weeks (2w)
+func parseAge(age string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if strings.HasSuffix(age, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(age, suffix))
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %s", age)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(age)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s", age)
+	}
+	return d, nil
+}
+
+func init() {
+	environmentsCmd.Init()
+	environmentsListCmd.Init()
+	environmentsCreateCmd.Init()
+	environmentsEditCmd.Init()
+	environmentsDeleteCmd.Init()
+	environmentsStopCmd.Init()
+	environmentsDeploymentsCmd.Init()
+	environmentsCleanupCmd.Init()
+	AddDefaultColumns(deployEnvironment{}, "id", "name", "state", "external_url")
+	AddDefaultColumns(deployment{}, "id", "ref", "sha", "user.username", "status", "created_at")
+}
@@ -0,0 +1,118 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("environments command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `deployments` sub command is executed", func() {
+		It("lists the deployments of the environment given as argument", func() {
+			mux.HandleFunc("/api/v4/projects/1/deployments", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("environment")).To(Equal("production"))
+				fmt.Fprint(w, `[{"id":7,"ref":"master"}]`)
+			})
+
+			out, _, err := executeCommand(RootCmd, "environments", "deployments", "-i", "1", "production")
+			Expect(err).To(BeNil())
+			Expect(out).To(ContainSubstring(`"ref": "master"`))
+		})
+	})
+
+	Context("when the `cleanup` sub command is executed", func() {
+		var requests []string
+
+		BeforeEach(func() {
+			requests = nil
+			recent := time.Now().Add(-24 * time.Hour).Format(time.RFC3339)
+			mux.HandleFunc("/api/v4/projects/1/environments", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"id":1,"name":"production"},{"id":2,"name":"review/old"},{"id":3,"name":"review/new"},{"id":4,"name":"review/never"}]`)
+			})
+			mux.HandleFunc("/api/v4/projects/1/environments/", func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				switch r.URL.Path {
+				case "/api/v4/projects/1/environments/2":
+					fmt.Fprint(w, `{"id":2,"name":"review/old","state":"available","last_deployment":{"created_at":"2018-01-01T10:00:00Z"}}`)
+				case "/api/v4/projects/1/environments/3":
+					fmt.Fprintf(w, `{"id":3,"name":"review/new","state":"available","last_deployment":{"created_at":"%s"}}`, recent)
+				case "/api/v4/projects/1/environments/4":
+					fmt.Fprint(w, `{"id":4,"name":"review/never","state":"stopped"}`)
+				default:
+					fmt.Fprint(w, `{}`)
+				}
+			})
+		})
+
+		It("stops and deletes stale environments matching the pattern", func() {
+			out, _, err := executeCommand(RootCmd, "environments", "cleanup", "-i", "1", "-n", "review/*", "-t", "7d")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("deleted review/old (last deployed 2018-01-01T10:00:00Z)\ndeleted review/never (never deployed)"))
+			Expect(requests).To(Equal([]string{
+				"GET /api/v4/projects/1/environments/2",
+				"POST /api/v4/projects/1/environments/2/stop",
+				"DELETE /api/v4/projects/1/environments/2",
+				"GET /api/v4/projects/1/environments/3",
+				"GET /api/v4/projects/1/environments/4",
+				"DELETE /api/v4/projects/1/environments/4",
+			}))
+		})
+
+		It("only prints the environments with `--dry_run`", func() {
+			out, _, err := executeCommand(RootCmd, "environments", "cleanup", "-i", "1", "-n", "review/*", "-t", "7d", "--dry_run")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("would delete review/old (last deployed 2018-01-01T10:00:00Z)\nwould delete review/never (never deployed)"))
+			for _, request := range requests {
+				Expect(request).To(HavePrefix("GET"))
+			}
+		})
+
+		It("refuses to clean up without `--older_than` unless `--all` is given", func() {
+			_, _, err := executeCommand(RootCmd, "environments", "cleanup", "-i", "1", "-n", "*", "-t", "7d", "--all")
+			Expect(err).To(MatchError("--older_than cannot be used together with --all"))
+			Expect(requests).To(BeEmpty())
+		})
+	})
+})
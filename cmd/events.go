@@ -0,0 +1,205 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// event is a contribution event as returned by the events API, the vendored
+// ProjectEvent lacks the creation date and push data
+type event struct {
+	ID             int                    `json:"id,omitempty"`
+	ProjectID      int                    `json:"project_id"`
+	ActionName     string                 `json:"action_name"`
+	TargetID       *int                   `json:"target_id"`
+	TargetIID      *int                   `json:"target_iid"`
+	TargetType     *string                `json:"target_type"`
+	TargetTitle    *string                `json:"target_title"`
+	AuthorID       int                    `json:"author_id"`
+	AuthorUsername string                 `json:"author_username"`
+	CreatedAt      *time.Time             `json:"created_at"`
+	PushData       map[string]interface{} `json:"push_data,omitempty"`
+	Note           map[string]interface{} `json:"note,omitempty"`
+}
+
+// see https://docs.gitlab.com/ce/api/events.html
+var eventsCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "events",
+		Aliases: []string{"event"},
+		Short:   "Activity events",
+		Long:    `List the contribution events of the authenticated user, of a user or of a project`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/events.html#list-currently-authenticated-user-s-events
+type eventsListFlags struct {
+	User       *string `flag_name:"user" short:"u" type:"integer/string" required:"no" description:"The ID or username of a user to list the contribution events of"`
+	Project    *string `flag_name:"project" short:"p" type:"integer/string" required:"no" description:"The ID or URL-encoded path of a project to list the events of"`
+	Action     *string `flag_name:"action" short:"a" type:"string" required:"no" description:"Include only events of a particular action type: created, updated, closed, reopened, pushed, commented, merged, joined, left, destroyed or expired"`
+	TargetType *string `flag_name:"target_type" short:"t" type:"string" required:"no" description:"Include only events of a particular target type: issue, milestone, merge_request, note, project, snippet or user"`
+	Before     *string `flag_name:"before" type:"string" required:"no" description:"Include only events created before a particular date (YYYY-MM-DD)"`
+	After      *string `flag_name:"after" type:"string" required:"no" description:"Include only events created after a particular date (YYYY-MM-DD)"`
+	Sort       *string `flag_name:"sort" type:"string" required:"no" description:"Sort events in asc or desc order by created_at. Default is desc"`
+	Follow     *bool   `flag_name:"follow" short:"f" type:"boolean" required:"no" description:"Poll for new events and print them as JSON lines"`
+	Interval   *int    `flag_name:"interval" type:"integer" required:"no" description:"Seconds to wait between two requests when following (default: 30)"`
+}
+
+type listEventsOptions struct {
+	gitlab.ListOptions
+	Action     *string `url:"action,omitempty" json:"action,omitempty"`
+	TargetType *string `url:"target_type,omitempty" json:"target_type,omitempty"`
+	Before     *string `url:"before,omitempty" json:"before,omitempty"`
+	After      *string `url:"after,omitempty" json:"after,omitempty"`
+	Sort       *string `url:"sort,omitempty" json:"sort,omitempty"`
+}
+
+var eventsListCmd = &golabCommand{
+	Parent: eventsCmd.Cmd,
+	Flags:  &eventsListFlags{},
+	Opts:   &listEventsOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List events",
+		Long: `Get a list of events for the authenticated user or, with --user or --project, the events of a user or project.
+
+With --follow, the newest events are polled until the command is interrupted and events that were created after the command started are printed as one JSON object per line, oldest first, e.g.
+
+    golab events ls -p my-group/my-project -a pushed --follow
+
+--sort cannot be used together with --follow.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*eventsListFlags)
+		opts := cmd.Opts.(*listEventsOptions)
+		if flags.User != nil && flags.Project != nil {
+			return errors.New("only one of --user or --project can be given")
+		}
+		path := "events"
+		switch {
+		case flags.User != nil:
+			path = "users/" + url.QueryEscape(*flags.User) + "/events"
+		case flags.Project != nil:
+			path = projectPath(*flags.Project, "/events")
+		}
+		if flags.Follow != nil && *flags.Follow {
+			if flags.Sort != nil {
+				return errors.New("--sort cannot be used together with --follow")
+			}
+			interval := 30 * time.Second
+			if flags.Interval != nil && *flags.Interval > 0 {
+				interval = time.Duration(*flags.Interval) * time.Second
+			}
+			return followEvents(path, opts, interval)
+		}
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			var events []*event
+			resp, err := gitlabRequest("GET", path, opts, &events)
+			return events, resp, err
+		})
+	},
+}
+
+// followEvents polls the events and prints all events that are newer than
+// the newest event of the previous request, oldest first. Each request pages
+// forward until it reaches an already printed event. The events of the first
+// request are not printed, if it returns no events, all events of the next
+// requests are new. Following stops on the first failing request.
+func followEvents(path string, opts *listEventsOptions, interval time.Duration) error {
+	var newest *event
+	for first := true; ; first = false {
+		events, err := eventsNewerThan(path, opts, newest, first)
+		if err != nil {
+			return err
+		}
+		if len(events) > 0 {
+			newest = events[0]
+		}
+		for i := len(events) - 1; i >= 0 && !first; i-- {
+			line, err := json.Marshal(events[i])
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(line))
+		}
+		sleep(interval)
+	}
+}
+
+// eventsNewerThan returns the events that are newer than the given event or
+// all events if it is nil, newest first. If firstPageOnly is set, only the
+// first page is requested.
+func eventsNewerThan(path string, opts *listEventsOptions, newest *event, firstPageOnly bool) ([]*event, error) {
+	pageOpts := *opts
+	pageOpts.Page = 1
+	if pageOpts.PerPage == 0 {
+		pageOpts.PerPage = maxPerPage
+	}
+	var result []*event
+	for {
+		var events []*event
+		resp, err := gitlabRequest("GET", path, &pageOpts, &events)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			if !firstPageOnly && newest != nil && !e.newerThan(newest) {
+				return result, nil
+			}
+			result = append(result, e)
+		}
+		next := nextPage(resp)
+		if firstPageOnly || next == 0 {
+			return result, nil
+		}
+		pageOpts.Page = next
+	}
+}
+
+// newerThan compares the IDs of the events or, as older Gitlab versions don't
+// return event IDs, their creation dates
+func (e *event) newerThan(other *event) bool {
+	if e.ID != 0 && other.ID != 0 {
+		return e.ID > other.ID
+	}
+	return e.CreatedAt != nil && other.CreatedAt != nil && e.CreatedAt.After(*other.CreatedAt)
+}
+
+func init() {
+	AddDefaultColumns(event{}, "created_at", "author_username", "action_name", "target_type", "target_title")
+	eventsCmd.Init()
+	eventsListCmd.Init()
+}
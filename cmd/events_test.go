@@ -0,0 +1,128 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("events command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+		sleep = func(time.Duration) {}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		sleep = time.Sleep
+	})
+	Context("when the `ls` sub command is executed", func() {
+		It("lists the filtered events of a project", func() {
+			mux.HandleFunc("/api/v4/projects/my-group/my-project/events", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("action")).To(Equal("pushed"))
+				Expect(r.URL.Query().Get("after")).To(Equal("2018-01-01"))
+				fmt.Fprint(w, `[{"id":2,"action_name":"pushed to","author_username":"jdoe"}]`)
+			})
+
+			out, _, err := executeCommand(RootCmd, "events", "ls", "-p", "my-group/my-project", "-a", "pushed", "--after", "2018-01-01")
+			Expect(err).To(BeNil())
+			Expect(out).To(ContainSubstring("jdoe"))
+			Expect(out).To(ContainSubstring(`"action_name": "pushed to"`))
+		})
+	})
+
+	Context("when the `ls` sub command is executed with --follow", func() {
+		It("prints the events created after the first request as JSON lines", func() {
+			// the second poll returns two pages of new events
+			responses := []string{
+				`[{"id":2,"action_name":"pushed to"},{"id":1,"action_name":"opened"}]`,
+				`[{"id":5,"action_name":"merged"},{"id":4,"action_name":"commented on"}]`,
+				`[{"id":3,"action_name":"opened"},{"id":2,"action_name":"pushed to"}]`,
+			}
+			mux.HandleFunc("/api/v4/projects/my-group/my-project/events", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("sort")).To(Equal(""))
+				if len(responses) == 0 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if len(responses) == 2 {
+					w.Header().Set("X-Next-Page", "2")
+				}
+				fmt.Fprint(w, responses[0])
+				responses = responses[1:]
+			})
+
+			out, _, err := executeCommand(RootCmd, "events", "ls", "-p", "my-group/my-project", "--follow")
+			Expect(err).NotTo(BeNil())
+			lines := strings.Split(out, "\n")
+			Expect(lines).To(HaveLen(3))
+			Expect(lines[0]).To(HavePrefix(`{"id":3,"project_id":0,"action_name":"opened"`))
+			Expect(lines[1]).To(HavePrefix(`{"id":4,`))
+			Expect(lines[2]).To(HavePrefix(`{"id":5,`))
+		})
+
+		It("prints all events of later requests if the first request returned no events", func() {
+			// the created_at dates are behind the local clock
+			responses := []string{
+				`[]`,
+				`[{"id":2,"action_name":"pushed to","created_at":"2018-01-01T10:00:00Z"},{"id":1,"action_name":"opened","created_at":"2018-01-01T09:00:00Z"}]`,
+			}
+			mux.HandleFunc("/api/v4/projects/my-group/my-project/events", func(w http.ResponseWriter, r *http.Request) {
+				if len(responses) == 0 {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				fmt.Fprint(w, responses[0])
+				responses = responses[1:]
+			})
+
+			out, _, err := executeCommand(RootCmd, "events", "ls", "-p", "my-group/my-project", "--follow")
+			Expect(err).NotTo(BeNil())
+			lines := strings.Split(out, "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(HavePrefix(`{"id":1,`))
+			Expect(lines[1]).To(HavePrefix(`{"id":2,`))
+		})
+
+		It("rejects --sort", func() {
+			_, _, err := executeCommand(RootCmd, "events", "ls", "-p", "my-group/my-project", "--follow", "--sort", "asc")
+			Expect(err).To(MatchError("--sort cannot be used together with --follow"))
+		})
+	})
+})
@@ -0,0 +1,264 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+	"gopkg.in/yaml.v2"
+)
+
+// see https://docs.gitlab.com/ce/api/features.html
+var featuresCmd = &golabCommand{
+	Parent: adminCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:     "features",
+		Aliases: []string{"feature"},
+		Short:   "Manage feature flags",
+		Long:    `List and change the feature flags of the Gitlab instance and copy them between instances`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/features.html#list-all-features
+var featuresListCmd = &golabCommand{
+	Parent: featuresCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List all features",
+		Long:  `Get a list of all persisted features, with its gate values.`,
+	},
+	Run: func(cmd golabCommand) error {
+		features, _, err := gitlabClient.Features.ListFeatures()
+		if err != nil {
+			return err
+		}
+		return OutputJson(features)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/features.html#set-or-create-a-feature
+var featuresSetCmd = &golabCommand{
+	Parent: featuresCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "set <name> <true|false|percentage>",
+		Short: "Set or create a feature",
+		Long: `Set a feature's gate value. If a feature with the given name doesn't exist yet it will be created. The value can be a boolean, or an integer to indicate percentage of time.
+
+Example:
+
+    golab admin features set my_feature 25`,
+		Args: cobra.ExactArgs(2),
+	},
+	Run: func(cmd golabCommand) error {
+		value, err := parseFeatureValue(cmd.Args[1])
+		if err != nil {
+			return err
+		}
+		feature, _, err := gitlabClient.Features.SetFeatureFlag(cmd.Args[0], value)
+		if err != nil {
+			return err
+		}
+		return OutputJson(feature)
+	},
+}
+
+var featuresExportCmd = &golabCommand{
+	Parent: featuresCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "export",
+		Short: "Export the state of all features",
+		Long: `Print the state of all features as map of feature names to values that can be read by 'admin features apply'. Values are true or false or the percentage of time a feature is enabled, other gates (e.g. for actors or groups) are not exported.
+
+Example:
+
+    golab admin features export -o yaml -e production > features.yaml`,
+	},
+	Run: func(cmd golabCommand) error {
+		features, _, err := gitlabClient.Features.ListFeatures()
+		if err != nil {
+			return err
+		}
+		values := map[string]interface{}{}
+		for _, feature := range features {
+			values[feature.Name] = featureValue(feature)
+		}
+		return OutputJson(values)
+	},
+}
+
+type featuresApplyFlags struct {
+	File   *string `flag_name:"file" short:"f" type:"string" required:"yes" description:"YAML or JSON file with the feature values, '-' reads from stdin"`
+	DryRun *bool   `flag_name:"dry_run" type:"boolean" required:"no" description:"Only print the features that would be changed"`
+}
+
+var featuresApplyCmd = &golabCommand{
+	Parent: featuresCmd.Cmd,
+	Flags:  &featuresApplyFlags{},
+	Cmd: &cobra.Command{
+		Use:   "apply",
+		Short: "Apply exported feature values",
+		Long: `Set all features of a file created by 'admin features export' whose values differ from the current values and print the changed features as
+
+    name: current value -> desired value
+
+Features that are not in the file are left untouched.
+
+Example:
+
+    golab admin features apply -f features.yaml -e staging`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*featuresApplyFlags)
+		content, err := readFileOrStdin(*flags.File)
+		if err != nil {
+			return err
+		}
+		var parsed interface{}
+		if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
+			return err
+		}
+		desired, ok := normalizeYaml(parsed).(map[string]interface{})
+		if !ok {
+			return errors.New("features file has to contain a map of feature names to values")
+		}
+		return applyFeatures(desired, flags.DryRun != nil && *flags.DryRun)
+	},
+}
+
+// applyFeatures sets all features whose current value differs from the
+// desired value, features that don't exist yet are created
+func applyFeatures(desired map[string]interface{}, dryRun bool) error {
+	features, _, err := gitlabClient.Features.ListFeatures()
+	if err != nil {
+		return err
+	}
+	current := map[string]interface{}{}
+	for _, feature := range features {
+		current[feature.Name] = featureValue(feature)
+	}
+	var names []string
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	changed := false
+	for _, name := range names {
+		value, err := featureFlagValue(desired[name])
+		if err != nil {
+			return fmt.Errorf("invalid value for feature %s: %s", name, err)
+		}
+		if value == current[name] {
+			continue
+		}
+		if !dryRun {
+			if err := setFeature(name, current[name], value); err != nil {
+				return err
+			}
+		}
+		from, _ := json.Marshal(current[name])
+		fmt.Printf("%s: %s -> %v\n", name, from, value)
+		changed = true
+	}
+	if !changed {
+		fmt.Println("features are up to date")
+	}
+	return nil
+}
+
+// setFeature changes a feature from the current to the given value. Gitlab
+// keeps the boolean gate when a percentage is set, so a feature that is on is
+// switched off first.
+func setFeature(name string, current interface{}, value interface{}) error {
+	if _, percentage := value.(int); percentage && current == true {
+		if _, _, err := gitlabClient.Features.SetFeatureFlag(name, false); err != nil {
+			return err
+		}
+	}
+	_, _, err := gitlabClient.Features.SetFeatureFlag(name, value)
+	return err
+}
+
+// featureValue returns the value of the boolean or percentage of time gate
+// of a feature
+func featureValue(feature *gitlab.Feature) interface{} {
+	if feature.State == "on" {
+		return true
+	}
+	for _, gate := range feature.Gates {
+		if gate.Key == "percentage_of_time" && gate.Value != nil {
+			if percentage, err := featureFlagValue(gate.Value); err == nil {
+				return percentage
+			}
+		}
+	}
+	return false
+}
+
+// featureFlagValue checks that value is a boolean or an integer percentage,
+// numbers are returned as int
+func featureFlagValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case bool, int:
+		return featureFlagValue(fmt.Sprint(v))
+	case float64:
+		if v == math.Trunc(v) {
+			return featureFlagValue(strconv.Itoa(int(v)))
+		}
+	case string:
+		return parseFeatureValue(v)
+	}
+	return nil, fmt.Errorf("expected true, false or a percentage, got %v", value)
+}
+
+func parseFeatureValue(value string) (interface{}, error) {
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	percentage, err := strconv.Atoi(value)
+	if err != nil || percentage < 0 || percentage > 100 {
+		return nil, fmt.Errorf("expected true, false or a percentage, got %s", value)
+	}
+	return percentage, nil
+}
+
+func init() {
+	AddDefaultColumns(gitlab.Feature{}, "name", "state")
+	featuresCmd.Init()
+	featuresListCmd.Init()
+	featuresSetCmd.Init()
+	featuresExportCmd.Init()
+	featuresApplyCmd.Init()
+}
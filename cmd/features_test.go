@@ -0,0 +1,127 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("features command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `export` sub command is executed", func() {
+		It("outputs the boolean and percentage values of all features", func() {
+			mux.HandleFunc("/api/v4/features", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"name":"a","state":"on","gates":[{"key":"boolean","value":true}]},
+					{"name":"b","state":"conditional","gates":[{"key":"boolean","value":false},{"key":"percentage_of_time","value":30}]},
+					{"name":"c","state":"off","gates":[{"key":"boolean","value":false}]}]`)
+			})
+
+			out, _, err := executeCommand(RootCmd, "admin", "features", "export")
+			Expect(err).To(BeNil())
+			Expect(out).To(MatchJSON(`{"a":true,"b":30,"c":false}`))
+		})
+	})
+
+	Context("when the `apply` sub command is executed", func() {
+		It("sets all features whose values differ", func() {
+			set := map[string]interface{}{}
+			mux.HandleFunc("/api/v4/features", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"name":"a","state":"on","gates":[{"key":"boolean","value":true}]},
+					{"name":"b","state":"off","gates":[{"key":"boolean","value":false}]}]`)
+			})
+			mux.HandleFunc("/api/v4/features/", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal("POST"))
+				var body map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&body)
+				set[r.URL.Path] = body["value"]
+				fmt.Fprint(w, `{}`)
+			})
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			w.WriteString("a: true\nb: 25\nc: true\n")
+			w.Close()
+			os.Stdin = r
+
+			out, _, err := executeCommand(RootCmd, "admin", "features", "apply", "-f", "-")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("b: false -> 25\nc: null -> true"))
+			Expect(set).To(Equal(map[string]interface{}{"/api/v4/features/b": float64(25), "/api/v4/features/c": true}))
+		})
+
+		It("switches a feature off before setting a percentage", func() {
+			var set []string
+			mux.HandleFunc("/api/v4/features", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"name":"a","state":"on","gates":[{"key":"boolean","value":true}]}]`)
+			})
+			mux.HandleFunc("/api/v4/features/a", func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&body)
+				set = append(set, fmt.Sprint(body["value"]))
+				fmt.Fprint(w, `{}`)
+			})
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			w.WriteString("a: 25\n")
+			w.Close()
+			os.Stdin = r
+
+			out, _, err := executeCommand(RootCmd, "admin", "features", "apply", "-f", "-")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("a: true -> 25"))
+			Expect(set).To(Equal([]string{"false", "25"}))
+		})
+	})
+})
+
+var _ = Describe("parseFeatureValue", func() {
+	It("accepts booleans and percentages only", func() {
+		Expect(parseFeatureValue("1")).To(Equal(1))
+		Expect(parseFeatureValue("false")).To(Equal(false))
+		_, err := parseFeatureValue("101")
+		Expect(err).NotTo(BeNil())
+	})
+})
@@ -0,0 +1,286 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"os"
+	"unicode/utf8"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// see https://docs.gitlab.com/ce/api/repository_files.html
+var filesCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "files",
+		Aliases: []string{"file"},
+		Short:   "Manage repository files",
+		Long: `Get, create, update and delete files in a repository without cloning it.
+
+Content for create and update is given with --content or read from a local file with --content_file ('-' reads from stdin). Binary content is base64 encoded automatically.`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/repository_files.html#get-file-from-repository
+type filesGetFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	FilePath *string `flag_name:"file_path" short:"f" type:"string" required:"yes" description:"Full path to the file, e.g. lib/class.rb"`
+	Ref      *string `flag_name:"ref" short:"r" type:"string" required:"yes" description:"The name of branch, tag or commit"`
+}
+
+var filesGetCmd = &golabCommand{
+	Parent: filesCmd.Cmd,
+	Flags:  &filesGetFlags{},
+	Opts:   &gitlab.GetFileOptions{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get file from repository",
+		Long: `Receive information about a file in the repository like name, size and content.
+
+The content of text files is decoded, binary content is returned base64 encoded.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*filesGetFlags)
+		opts := cmd.Opts.(*gitlab.GetFileOptions)
+		file, _, err := gitlabClient.RepositoryFiles.GetFile(*flags.Id, *flags.FilePath, opts)
+		if err != nil {
+			return err
+		}
+		if file.Encoding == "base64" {
+			content, err := base64.StdEncoding.DecodeString(file.Content)
+			if err == nil && utf8.Valid(content) {
+				file.Content = string(content)
+				file.Encoding = "text"
+			}
+		}
+		return OutputJson(file)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/repository_files.html#get-raw-file-from-repository
+type filesRawFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	FilePath *string `flag_name:"file_path" short:"f" type:"string" required:"yes" description:"Full path to the file, e.g. lib/class.rb"`
+	Ref      *string `flag_name:"ref" short:"r" type:"string" required:"yes" description:"The name of branch, tag or commit"`
+}
+
+var filesRawCmd = &golabCommand{
+	Parent: filesCmd.Cmd,
+	Flags:  &filesRawFlags{},
+	Opts:   &gitlab.GetRawFileOptions{},
+	Cmd: &cobra.Command{
+		Use:   "raw",
+		Short: "Get raw file from repository",
+		Long:  `Prints the raw content of a file in the repository, e.g. 'golab files raw -i 1 -f logo.png -r master > logo.png'`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*filesRawFlags)
+		opts := cmd.Opts.(*gitlab.GetRawFileOptions)
+		content, _, err := gitlabClient.RepositoryFiles.GetRawFile(*flags.Id, *flags.FilePath, opts)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(content)
+		return err
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/repository_files.html#create-new-file-in-repository
+type filesCreateFlags struct {
+	Id            *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	FilePath      *string `flag_name:"file_path" short:"f" type:"string" required:"yes" description:"Full path to the new file, e.g. lib/class.rb"`
+	Branch        *string `flag_name:"branch" short:"b" type:"string" required:"yes" description:"Name of the branch"`
+	Encoding      *string `flag_name:"encoding" type:"string" required:"no" description:"Change encoding to 'base64'. Default is text, binary content is base64 encoded automatically."`
+	AuthorEmail   *string `flag_name:"author_email" type:"string" required:"no" description:"Specify the commit author's email address"`
+	AuthorName    *string `flag_name:"author_name" type:"string" required:"no" description:"Specify the commit author's name"`
+	Content       *string `flag_name:"content" short:"c" type:"string" required:"no" description:"File content"`
+	ContentFile   *string `flag_name:"content_file" type:"string" required:"no" description:"Local file to read the file content from, '-' reads from stdin"`
+	CommitMessage *string `flag_name:"commit_message" short:"m" type:"string" required:"yes" description:"Commit message"`
+}
+
+var filesCreateCmd = &golabCommand{
+	Parent: filesCmd.Cmd,
+	Flags:  &filesCreateFlags{},
+	Opts:   &gitlab.CreateFileOptions{},
+	Cmd: &cobra.Command{
+		Use:   "create",
+		Short: "Create new file in repository",
+		Long:  `Allows you to create a single file.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*filesCreateFlags)
+		opts := cmd.Opts.(*gitlab.CreateFileOptions)
+		content, encoding, err := fileContent(flags.Content, flags.ContentFile, flags.Encoding)
+		if err != nil {
+			return err
+		}
+		opts.Content, opts.Encoding = &content, encoding
+		info, _, err := gitlabClient.RepositoryFiles.CreateFile(*flags.Id, *flags.FilePath, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(info)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/repository_files.html#update-existing-file-in-repository
+type filesUpdateFlags struct {
+	Id            *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	FilePath      *string `flag_name:"file_path" short:"f" type:"string" required:"yes" description:"Full path to the file, e.g. lib/class.rb"`
+	Branch        *string `flag_name:"branch" short:"b" type:"string" required:"yes" description:"Name of the branch"`
+	Encoding      *string `flag_name:"encoding" type:"string" required:"no" description:"Change encoding to 'base64'. Default is text, binary content is base64 encoded automatically."`
+	AuthorEmail   *string `flag_name:"author_email" type:"string" required:"no" description:"Specify the commit author's email address"`
+	AuthorName    *string `flag_name:"author_name" type:"string" required:"no" description:"Specify the commit author's name"`
+	Content       *string `flag_name:"content" short:"c" type:"string" required:"no" description:"File content"`
+	ContentFile   *string `flag_name:"content_file" type:"string" required:"no" description:"Local file to read the file content from, '-' reads from stdin"`
+	CommitMessage *string `flag_name:"commit_message" short:"m" type:"string" required:"yes" description:"Commit message"`
+	LastCommitID  *string `flag_name:"last_commit_id" type:"string" required:"no" description:"Last known file commit id"`
+}
+
+var filesUpdateCmd = &golabCommand{
+	Parent: filesCmd.Cmd,
+	Flags:  &filesUpdateFlags{},
+	Opts:   &gitlab.UpdateFileOptions{},
+	Cmd: &cobra.Command{
+		Use:   "update",
+		Short: "Update existing file in repository",
+		Long: `Allows you to update a single file.
+
+If the file was changed since --last_commit_id, the update fails - use this to not overwrite concurrent changes.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*filesUpdateFlags)
+		opts := cmd.Opts.(*gitlab.UpdateFileOptions)
+		content, encoding, err := fileContent(flags.Content, flags.ContentFile, flags.Encoding)
+		if err != nil {
+			return err
+		}
+		opts.Content, opts.Encoding = &content, encoding
+		info, _, err := gitlabClient.RepositoryFiles.UpdateFile(*flags.Id, *flags.FilePath, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(info)
+	},
+}
+
+// fileContent returns the content for a file in the repository and its
+// encoding. Content that is no valid UTF-8 is base64 encoded.
+func fileContent(content, contentFile, encoding *string) (string, *string, error) {
+	c, err := contentFromFlags(content, contentFile, "content", "content_file")
+	if err != nil {
+		return "", nil, err
+	}
+	if encoding != nil && *encoding == "base64" {
+		if contentFile != nil {
+			// content read from files is never encoded
+			c = base64.StdEncoding.EncodeToString([]byte(c))
+		}
+		return c, encoding, nil
+	}
+	if !utf8.ValidString(c) {
+		return base64.StdEncoding.EncodeToString([]byte(c)), gitlab.String("base64"), nil
+	}
+	return c, encoding, nil
+}
+
+// see https://docs.gitlab.com/ce/api/repository_files.html#delete-existing-file-in-repository
+type filesDeleteFlags struct {
+	Id            *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	FilePath      *string `flag_name:"file_path" short:"f" type:"string" required:"yes" description:"Full path to the file, e.g. lib/class.rb"`
+	Branch        *string `flag_name:"branch" short:"b" type:"string" required:"yes" description:"Name of the branch"`
+	AuthorEmail   *string `flag_name:"author_email" type:"string" required:"no" description:"Specify the commit author's email address"`
+	AuthorName    *string `flag_name:"author_name" type:"string" required:"no" description:"Specify the commit author's name"`
+	CommitMessage *string `flag_name:"commit_message" short:"m" type:"string" required:"yes" description:"Commit message"`
+}
+
+var filesDeleteCmd = &golabCommand{
+	Parent: filesCmd.Cmd,
+	Flags:  &filesDeleteFlags{},
+	Opts:   &gitlab.DeleteFileOptions{},
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Delete existing file in repository",
+		Long:  `This allows you to delete a single file.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*filesDeleteFlags)
+		opts := cmd.Opts.(*gitlab.DeleteFileOptions)
+		_, err := gitlabClient.RepositoryFiles.DeleteFile(*flags.Id, *flags.FilePath, opts)
+		return err
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/repository_files.html#get-file-blame-from-repository
+type filesBlameFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	FilePath *string `flag_name:"file_path" short:"f" type:"string" required:"yes" description:"Full path to the file, e.g. lib/class.rb"`
+	Ref      *string `flag_name:"ref" short:"r" type:"string" required:"yes" description:"The name of branch, tag or commit"`
+}
+
+// blameRange is a range of lines of a file that was last changed by commit,
+// the vendored RepositoryFilesService does not support blames
+type blameRange struct {
+	Commit *gitlab.Commit `json:"commit"`
+	Lines  []string       `json:"lines"`
+}
+
+var filesBlameCmd = &golabCommand{
+	Parent: filesCmd.Cmd,
+	Flags:  &filesBlameFlags{},
+	Opts:   &gitlab.GetFileOptions{},
+	Cmd: &cobra.Command{
+		Use:   "blame",
+		Short: "Get file blame from repository",
+		Long:  `Allows you to receive blame information. Each blame range contains lines and the corresponding commit information.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*filesBlameFlags)
+		opts := cmd.Opts.(*gitlab.GetFileOptions)
+		var blame []*blameRange
+		_, err := gitlabRequest("GET", projectPath(*flags.Id, "/repository/files/%s/blame", url.PathEscape(*flags.FilePath)), opts, &blame)
+		if err != nil {
+			return err
+		}
+		return OutputJson(blame)
+	},
+}
+
+func init() {
+	filesCmd.Init()
+	filesGetCmd.Init()
+	filesRawCmd.Init()
+	filesCreateCmd.Init()
+	filesUpdateCmd.Init()
+	filesDeleteCmd.Init()
+	filesBlameCmd.Init()
+	AddDefaultColumns(blameRange{}, "commit.short_id", "commit.author_name", "commit.authored_date", "lines")
+}
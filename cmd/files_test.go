@@ -0,0 +1,89 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("files command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when the `get` sub command is executed for a text file", func() {
+		It("prints the decoded content", func() {
+			mux.HandleFunc("/api/v4/projects/1/repository/files/config.yml", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("ref")).To(Equal("master"))
+				fmt.Fprint(w, `{"file_path":"config.yml","encoding":"base64","content":"a2V5OiB2YWx1ZQo="}`)
+			})
+			out, _, err := executeCommand(RootCmd, "files", "get", "-i", "1", "-f", "config.yml", "-r", "master")
+			Expect(err).To(BeNil())
+			Expect(out).To(ContainSubstring(`"content": "key: value\n"`))
+			Expect(out).To(ContainSubstring(`"encoding": "text"`))
+		})
+	})
+
+	Context("when the `update` sub command is executed with binary content from stdin", func() {
+		It("sends the content base64 encoded", func() {
+			var body map[string]string
+			mux.HandleFunc("/api/v4/projects/1/repository/files/logo.png", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal("PUT"))
+				json.NewDecoder(r.Body).Decode(&body)
+				fmt.Fprint(w, `{"file_path":"logo.png","branch":"master"}`)
+			})
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			w.Write([]byte{0x89, 0x50, 0x4e, 0x47, 0xff})
+			w.Close()
+			os.Stdin = r
+
+			_, _, err := executeCommand(RootCmd, "files", "update", "-i", "1", "-f", "logo.png", "-b", "master", "-m", "update logo", "--content_file", "-")
+			Expect(err).To(BeNil())
+			Expect(body["encoding"]).To(Equal("base64"))
+			Expect(body["content"]).To(Equal("iVBOR/8="))
+			Expect(body["branch"]).To(Equal("master"))
+		})
+	})
+})
@@ -0,0 +1,72 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// gitlabRequest sends a request for API endpoints that are not (fully)
+// covered by the vendored go-gitlab services. opt is encoded as query for GET
+// and DELETE and as JSON body for POST and PUT, the response is decoded into v.
+// Maps can be used as opt for POST and PUT only.
+func gitlabRequest(method, path string, opt interface{}, v interface{}) (*gitlab.Response, error) {
+	if opt != nil && reflect.ValueOf(opt).Kind() == reflect.Map {
+		return gitlabMapRequest(method, path, opt, v)
+	}
+	req, err := gitlabClient.NewRequest(method, path, opt, nil)
+	if err != nil {
+		return nil, err
+	}
+	return gitlabClient.Do(req, v)
+}
+
+// gitlabMapRequest sends opt as JSON body, the vendored client can only
+// encode structs as query
+func gitlabMapRequest(method, path string, opt interface{}, v interface{}) (*gitlab.Response, error) {
+	if method != "POST" && method != "PUT" {
+		return nil, fmt.Errorf("cannot send %s request with map options", method)
+	}
+	body, err := json.Marshal(opt)
+	if err != nil {
+		return nil, err
+	}
+	req, err := gitlabClient.NewRequest(method, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	return gitlabClient.Do(req, v)
+}
+
+// projectPath returns the API path for a project resource, pid is the ID or
+// path of the project
+func projectPath(pid string, format string, a ...interface{}) string {
+	return "projects/" + url.QueryEscape(pid) + fmt.Sprintf(format, a...)
+}
@@ -35,6 +35,7 @@ type golabCommand struct {
 	Run    func(cmd golabCommand) error
 	Mapper mapper.FlagMapper
 	Cmd    *cobra.Command
+	Args   []string
 }
 
 func (c golabCommand) Execute() error {
@@ -69,6 +70,7 @@ func applyPagination(c golabCommand) error {
 
 func (c golabCommand) Init() error {
 	c.Cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		c.Args = args
 		return c.Execute()
 	}
 	c.Mapper = mapper.InitializedMapper(c.Cmd, c.Flags, c.Opts)
@@ -81,5 +83,7 @@ func setPaginationFlags(c golabCommand) {
 	if c.Paged {
 		c.Cmd.PersistentFlags().Int("page", 0, "(optional) Page of results to retrieve")
 		c.Cmd.PersistentFlags().Int("per_page", 0, "(optional) The number of results to include per page (max 100)")
+		c.Cmd.PersistentFlags().Bool("all", false, "(optional) Retrieve all pages of results")
+		c.Cmd.PersistentFlags().Int("limit", 0, "(optional) Maximum number of results to retrieve from all pages")
 	}
 }
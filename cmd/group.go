@@ -60,11 +60,10 @@ var groupLsCmd = &golabCommand{
 		Long:  `Get a list of visible groups for the authenticated user.`,
 	},
 	Run: func(cmd golabCommand) error {
-		groups, _, err := gitlabClient.Groups.ListGroups(cmd.Opts.(*gitlab.ListGroupsOptions))
-		if err != nil {
-			return err
-		}
-		return OutputJson(groups)
+		opts := cmd.Opts.(*gitlab.ListGroupsOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			return gitlabClient.Groups.ListGroups(opts)
+		})
 	},
 }
 
@@ -93,11 +92,10 @@ var groupProjectsCmd = &golabCommand{
 	},
 	Run: func(cmd golabCommand) error {
 		flags := cmd.Flags.(*listGroupProjectsFlags)
-		projects, _, err := gitlabClient.Groups.ListGroupProjects(*flags.Id, cmd.Opts.(*gitlab.ListGroupProjectsOptions))
-		if err != nil {
-			return err
-		}
-		return OutputJson(projects)
+		opts := cmd.Opts.(*gitlab.ListGroupProjectsOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			return gitlabClient.Groups.ListGroupProjects(*flags.Id, opts)
+		})
 	},
 }
 
@@ -52,10 +52,7 @@ var groupMembersLsCmd = &cobra.Command{
 		if id == 0 {
 			return errors.New("required parameter `-i` or `--id`not given - exiting")
 		}
-		opts := &gitlab.ListGroupMembersOptions{
-			ListOptions: gitlab.ListOptions{Page: 1, PerPage: 1000},
-		}
-		members, _, err := gitlabClient.Groups.ListGroupMembers(id, opts)
+		members, err := listAllGroupMembers(id)
 		if err != nil {
 			return err
 		}
@@ -186,20 +183,18 @@ var groupMemberSyncCmd = &cobra.Command{
 			return errors.New("required parameter `--target` not given - exiting")
 		}
 
-		opts := &gitlab.ListGroupMembersOptions{
-			ListOptions: gitlab.ListOptions{Page: 1, PerPage: 1000},
+		if err := createNonExistingTargetUsers(source, target); err != nil {
+			return err
 		}
 
-		createNonExistingTargetUsers(source, target, opts)
-
 		if remove {
-			err := removeTargetMembers(target, source, opts)
+			err := removeTargetMembers(target, source)
 			if err != nil {
 				return err
 			}
 		}
 
-		members, _, err := gitlabClient.Groups.ListGroupMembers(target, opts)
+		members, err := listAllGroupMembers(target)
 		if err != nil {
 			return err
 		}
@@ -207,8 +202,20 @@ var groupMemberSyncCmd = &cobra.Command{
 	},
 }
 
-func createNonExistingTargetUsers(source int, target int, opts *gitlab.ListGroupMembersOptions) error {
-	sourceMembers, _, err := gitlabClient.Groups.ListGroupMembers(source, opts)
+// listAllGroupMembers returns the members of a group from all result pages
+func listAllGroupMembers(gid int) ([]*gitlab.GroupMember, error) {
+	var members []*gitlab.GroupMember
+	opts := &gitlab.ListGroupMembersOptions{}
+	err := forEachPage(&opts.ListOptions, func() (*gitlab.Response, error) {
+		page, resp, err := gitlabClient.Groups.ListGroupMembers(gid, opts)
+		members = append(members, page...)
+		return resp, err
+	})
+	return members, err
+}
+
+func createNonExistingTargetUsers(source int, target int) error {
+	sourceMembers, err := listAllGroupMembers(source)
 	if err != nil {
 		return err
 	}
@@ -234,8 +241,8 @@ func createNonExistingTargetUsers(source int, target int, opts *gitlab.ListGroup
 	return nil
 }
 
-func removeTargetMembers(target int, source int, opts *gitlab.ListGroupMembersOptions) error {
-	targetMembers, _, err := gitlabClient.Groups.ListGroupMembers(target, opts)
+func removeTargetMembers(target int, source int) error {
+	targetMembers, err := listAllGroupMembers(target)
 	if err != nil {
 		return err
 	}
@@ -126,6 +126,26 @@ var _ = Describe("group-members command", func() {
 			Expect(method).To(Equal("GET"))
 			Expect(stdout).To(Equal(expected))
 		})
+
+		It("returns group members from all pages", func() {
+			defer server.Close()
+			var pages []string
+			mux.HandleFunc("/api/v4/groups/30/members", func(w http.ResponseWriter, r *http.Request) {
+				page := r.URL.Query().Get("page")
+				pages = append(pages, page)
+				if page == "1" {
+					w.Header().Set("X-Next-Page", "2")
+					fmt.Fprint(w, `[{"id":1,"username":"first"}]`)
+				} else {
+					fmt.Fprint(w, `[{"id":2,"username":"second"}]`)
+				}
+			})
+			stdout, _, err := executeCommand(RootCmd, "group-members", "ls", "-i", "30")
+			Expect(err).To(BeNil())
+			Expect(pages).To(Equal([]string{"1", "2"}))
+			Expect(stdout).To(ContainSubstring(`"username": "first"`))
+			Expect(stdout).To(ContainSubstring(`"username": "second"`))
+		})
 	})
 
 	Context("when teh `add` sub command is executed", func() {
@@ -41,6 +41,18 @@ func (g gitHelper) GetRemotes() (string, error) {
 	return string(out), err
 }
 
+func (g gitHelper) GetCurrentBranch() (string, error) {
+	out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return "", err
+	}
+	branch := strings.TrimSpace(string(out))
+	if branch == "HEAD" {
+		return "", errors.New("cannot determine current branch in detached HEAD state")
+	}
+	return branch, nil
+}
+
 func (g gitHelper) GetRemoteUrl(remotes string) (string, error) {
 	re := regexp.MustCompile("origin\\s*(.+?)\\s*\\(fetch\\)")
 	match := re.FindStringSubmatch(remotes)
@@ -62,6 +74,20 @@ func (g gitHelper) GetWebUrl(remoteUrl string) (string, error) {
 	}
 }
 
+// GetProjectPath returns the path with namespace of the Gitlab project for the
+// given remote URL, e.g. michaellihs/golab
+func (g gitHelper) GetProjectPath(remoteUrl string) (string, error) {
+	webUrl, err := g.GetWebUrl(remoteUrl)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(webUrl)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(u.Path, "/"), nil
+}
+
 func webifySshRemote(remoteUrl string) (string, error) {
 	u, err := url.Parse(remoteUrl)
 	if err != nil {
@@ -77,7 +103,7 @@ func webifySshRemote(remoteUrl string) (string, error) {
 }
 
 func webifyGitRemote(remoteUrl string) (string, error) {
-	url := strings.TrimRight(remoteUrl[4:], ".git")
+	url := strings.TrimSuffix(remoteUrl[4:], ".git")
 	url = strings.Replace(url, ":", "/", 1)
 	return "https://" + url, nil
 }
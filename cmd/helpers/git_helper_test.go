@@ -73,4 +73,16 @@ var _ = Describe("GitHelper", func() {
 
 	})
 
+	var _ = Describe("GetProjectPath", func() {
+
+		It("returns the project path for http(s) origin", func() {
+			Expect(gh.GetProjectPath("https://gitlab.com/my-group/sub-group/my-project.git")).To(Equal("my-group/sub-group/my-project"))
+		})
+
+		It("returns the project path for git origin", func() {
+			Expect(gh.GetProjectPath("git@gitlab.com:my-group/my-project.git")).To(Equal("my-group/my-project"))
+		})
+
+	})
+
 })
@@ -20,16 +20,15 @@
 
 package helpers
 
-import (
-	"encoding/json"
-	"fmt"
-)
+import "fmt"
 
+// OutputJson prints the given object in the output format selected with
+// SetOutputFormat - which is pretty-printed JSON by default
 func OutputJson(object interface{}) error {
-	result, err := json.MarshalIndent(object, "", "  ")
+	result, err := render(object)
 	if err != nil {
 		return err
 	}
-	fmt.Println(string(result))
+	fmt.Println(result)
 	return nil
 }
@@ -0,0 +1,327 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package helpers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// OutputFormats lists the formats that can be selected with SetOutputFormat
+var OutputFormats = []string{"json", "yaml", "table", "csv", "tsv", "template=<go-template>"}
+
+type outputConfig struct {
+	format   string
+	columns  []string
+	template *template.Template
+}
+
+var output = outputConfig{format: "json"}
+
+// defaultColumns holds the columns rendered for table, csv and tsv output,
+// if no columns are given. Keys are the type names of the rendered objects.
+var defaultColumns = map[string][]string{
+	"gitlab.Branch":             {"name", "commit.short_id", "merged", "protected"},
+	"gitlab.Commit":             {"short_id", "title", "author_name", "created_at"},
+	"gitlab.DeployKey":          {"id", "title", "can_push", "created_at"},
+	"gitlab.Email":              {"id", "email"},
+	"gitlab.Group":              {"id", "full_path", "visibility", "web_url"},
+	"gitlab.GroupMember":        {"id", "username", "name", "access_level", "expires_at"},
+	"gitlab.ImpersonationToken": {"id", "name", "active", "revoked", "expires_at"},
+	"gitlab.Label":              {"name", "color", "open_issues_count", "open_merge_requests_count"},
+	"gitlab.MergeRequest":       {"iid", "title", "state", "source_branch", "target_branch", "author.username"},
+	"gitlab.Namespace":          {"id", "full_path", "kind"},
+	"gitlab.Project":            {"id", "path_with_namespace", "visibility", "default_branch", "web_url"},
+	"gitlab.ProjectHook":        {"id", "url", "created_at"},
+	"gitlab.ProtectedBranch":    {"name"},
+	"gitlab.SSHKey":             {"id", "title", "created_at"},
+	"gitlab.User":               {"id", "username", "name", "email", "state"},
+	"gitlab.UserActivity":       {"username", "last_activity_on"},
+}
+
+// SetOutputFormat selects the format OutputJson renders objects in. Columns
+// select the fields shown by table, csv and tsv output, nested fields can be
+// addressed with a dot, e.g. author.username. An empty format selects JSON.
+func SetOutputFormat(format string, columns []string) error {
+	config := outputConfig{format: format, columns: columns}
+	switch {
+	case format == "":
+		config.format = "json"
+	case format == "json", format == "yaml", format == "table", format == "csv", format == "tsv":
+	case strings.HasPrefix(format, "template="):
+		tpl, err := template.New("output").Parse(strings.TrimPrefix(format, "template="))
+		if err != nil {
+			return err
+		}
+		config.format = "template"
+		config.template = tpl
+	default:
+		return fmt.Errorf("unknown output format '%s', use one of %s", format, strings.Join(OutputFormats, ", "))
+	}
+	output = config
+	return nil
+}
+
+// AddDefaultColumns registers the default columns for objects of the same
+// type as the given object
+func AddDefaultColumns(object interface{}, columns ...string) {
+	defaultColumns[typeName(reflect.TypeOf(object))] = columns
+}
+
+// FieldValue returns the value of a field of object as shown in table output,
+// field is a json field name or a path like author.username. Fields that do
+// not exist in object return an error.
+func FieldValue(object interface{}, field string) (string, error) {
+	generic, err := toGeneric(object)
+	if err != nil {
+		return "", err
+	}
+	value, ok := lookupField(generic, field)
+	if !ok {
+		return "", fmt.Errorf("unknown field %s", field)
+	}
+	return cellValue(value), nil
+}
+
+func render(object interface{}) (string, error) {
+	switch output.format {
+	case "yaml":
+		return renderYaml(object)
+	case "table":
+		return renderTable(object)
+	case "csv":
+		return renderSeparated(object, ',')
+	case "tsv":
+		return renderSeparated(object, '\t')
+	case "template":
+		return renderTemplate(object)
+	default:
+		result, err := json.MarshalIndent(object, "", "  ")
+		return string(result), err
+	}
+}
+
+func renderYaml(object interface{}) (string, error) {
+	generic, err := toGeneric(object)
+	if err != nil {
+		return "", err
+	}
+	result, err := yaml.Marshal(generic)
+	return strings.TrimSuffix(string(result), "\n"), err
+}
+
+func renderTemplate(object interface{}) (string, error) {
+	generic, err := toGeneric(object)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = output.template.Execute(&buf, generic)
+	return strings.TrimSuffix(buf.String(), "\n"), err
+}
+
+func renderTable(object interface{}) (string, error) {
+	columns, rows, err := toRows(object)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func renderSeparated(object interface{}, separator rune) (string, error) {
+	columns, rows, err := toRows(object)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = separator
+	w.Write(columns)
+	w.WriteAll(rows)
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// toGeneric converts the object into maps and slices keyed by the json names
+// of its fields, so that all formats use the same field names as the API
+func toGeneric(object interface{}) (interface{}, error) {
+	bytes, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	err = json.Unmarshal(bytes, &generic)
+	return generic, err
+}
+
+func toRows(object interface{}) ([]string, [][]string, error) {
+	generic, err := toGeneric(object)
+	if err != nil {
+		return nil, nil, err
+	}
+	var items []interface{}
+	switch v := generic.(type) {
+	case []interface{}:
+		items = v
+	case nil:
+	default:
+		items = []interface{}{v}
+	}
+	columns := output.columns
+	if len(columns) == 0 {
+		columns = columnsFor(object, items)
+	}
+	rows := [][]string{}
+	for _, item := range items {
+		rows = append(rows, toRow(item, columns))
+	}
+	return columns, rows, nil
+}
+
+func toRow(item interface{}, columns []string) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = cellValue(lookup(item, column))
+	}
+	return row
+}
+
+func columnsFor(object interface{}, items []interface{}) []string {
+	if columns, ok := defaultColumns[elementTypeName(object)]; ok {
+		return columns
+	}
+	if len(items) == 0 {
+		return []string{}
+	}
+	item, ok := items[0].(map[string]interface{})
+	if !ok {
+		return []string{"value"}
+	}
+	// fall back to all scalar fields of the first item, with id first
+	var columns []string
+	for key, value := range item {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			columns = append(columns, key)
+		}
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		if columns[i] == "id" || columns[j] == "id" {
+			return columns[i] == "id"
+		}
+		return columns[i] < columns[j]
+	})
+	return columns
+}
+
+func lookup(item interface{}, column string) interface{} {
+	value, _ := lookupField(item, column)
+	return value
+}
+
+// lookupField returns the value of a column of item and false, if a segment
+// of the column's path does not exist. Paths below null values are empty.
+func lookupField(item interface{}, column string) (interface{}, bool) {
+	if column == "value" {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return item, true
+		}
+	}
+	value := item
+	for _, key := range strings.Split(column, ".") {
+		if value == nil {
+			return nil, true
+		}
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func cellValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		result, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(result)
+	}
+}
+
+// elementTypeName returns the type name of the object or of the elements of
+// the object, if it is a slice
+func elementTypeName(object interface{}) string {
+	t := reflect.TypeOf(object)
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return typeName(t)
+}
+
+func typeName(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.String()
+}
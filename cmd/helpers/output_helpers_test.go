@@ -0,0 +1,101 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package helpers
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("OutputHelper", func() {
+
+	projects := []*gitlab.Project{
+		{ID: 1, Name: "first", PathWithNamespace: "group/first", Visibility: gitlab.PrivateVisibility, WebURL: "http://localhost/group/first"},
+		{ID: 2, Name: "second", PathWithNamespace: "group/second", Visibility: gitlab.PublicVisibility, DefaultBranch: "master", WebURL: "http://localhost/group/second"},
+	}
+
+	AfterEach(func() {
+		SetOutputFormat("json", nil)
+	})
+
+	It("renders JSON by default", func() {
+		Expect(SetOutputFormat("", nil)).To(Succeed())
+		result, err := render(map[string]int{"id": 1})
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("{\n  \"id\": 1\n}"))
+	})
+
+	It("rejects unknown output formats", func() {
+		Expect(SetOutputFormat("xml", nil)).NotTo(Succeed())
+	})
+
+	It("renders a table with the default columns of a type", func() {
+		Expect(SetOutputFormat("table", nil)).To(Succeed())
+		result, err := render(projects)
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal(`ID  PATH_WITH_NAMESPACE  VISIBILITY  DEFAULT_BRANCH  WEB_URL
+1   group/first          private                     http://localhost/group/first
+2   group/second         public      master          http://localhost/group/second`))
+	})
+
+	It("renders csv with the given columns", func() {
+		Expect(SetOutputFormat("csv", []string{"id", "name", "namespace.name"})).To(Succeed())
+		result, err := render(projects[0])
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("id,name,namespace.name\n1,first,"))
+	})
+
+	It("renders tsv", func() {
+		Expect(SetOutputFormat("tsv", []string{"id", "name"})).To(Succeed())
+		result, err := render(projects)
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("id\tname\n1\tfirst\n2\tsecond"))
+	})
+
+	It("renders yaml with the json field names", func() {
+		Expect(SetOutputFormat("yaml", nil)).To(Succeed())
+		result, err := render(&gitlab.Label{Name: "bug", Color: "#ff0000"})
+		Expect(err).To(BeNil())
+		Expect(result).To(ContainSubstring("name: bug"))
+		Expect(result).To(ContainSubstring("color: '#ff0000'"))
+	})
+
+	It("renders go templates", func() {
+		Expect(SetOutputFormat(`template={{range .}}{{.id}}:{{.name}} {{end}}`, nil)).To(Succeed())
+		result, err := render(projects)
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal("1:first 2:second "))
+	})
+
+	It("returns field values by json path", func() {
+		value, err := FieldValue(projects[1], "default_branch")
+		Expect(err).To(BeNil())
+		Expect(value).To(Equal("master"))
+		value, err = FieldValue(projects[1], "namespace.name")
+		Expect(err).To(BeNil())
+		Expect(value).To(Equal(""))
+		_, err = FieldValue(projects[1], "default_brunch")
+		Expect(err).To(MatchError("unknown field default_brunch"))
+		_, err = FieldValue(projects[1], "default_branch.name")
+		Expect(err).To(MatchError("unknown field default_branch.name"))
+	})
+})
@@ -0,0 +1,148 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package helpers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+
+	"gopkg.in/yaml.v2"
+)
+
+// OutputStream prints a list that arrives in chunks, e.g. the pages of a
+// paginated API result. JSON, YAML, CSV and TSV are printed as soon as a chunk
+// arrives, tables and templates need all items and are printed on Close.
+type OutputStream struct {
+	count    int
+	columns  []string
+	csv      *csv.Writer
+	buffered reflect.Value
+}
+
+func NewOutputStream() *OutputStream {
+	return &OutputStream{}
+}
+
+// Write prints the items of the given slice
+func (s *OutputStream) Write(items interface{}) error {
+	if output.format == "table" || output.format == "template" {
+		return s.buffer(items)
+	}
+	generic, err := toGeneric(items)
+	if err != nil {
+		return err
+	}
+	list, _ := generic.([]interface{})
+	switch output.format {
+	case "yaml":
+		err = s.writeYaml(list)
+	case "csv", "tsv":
+		err = s.writeSeparated(items, list)
+	default:
+		err = s.writeJson(list)
+	}
+	s.count += len(list)
+	return err
+}
+
+// Close prints everything that is still missing from the output
+func (s *OutputStream) Close() error {
+	switch output.format {
+	case "table", "template":
+		if !s.buffered.IsValid() {
+			return OutputJson([]interface{}{})
+		}
+		return OutputJson(s.buffered.Interface())
+	case "csv", "tsv":
+		if s.csv == nil {
+			return s.writeSeparated([]interface{}{}, nil)
+		}
+		return nil
+	default:
+		if s.count == 0 {
+			fmt.Println("[]")
+		} else if output.format == "json" {
+			fmt.Println("\n]")
+		}
+		return nil
+	}
+}
+
+func (s *OutputStream) buffer(items interface{}) error {
+	value := reflect.ValueOf(items)
+	if value.Kind() != reflect.Slice {
+		return fmt.Errorf("cannot stream %s, expected a slice", value.Type())
+	}
+	if !s.buffered.IsValid() {
+		s.buffered = reflect.MakeSlice(value.Type(), 0, value.Len())
+	}
+	s.buffered = reflect.AppendSlice(s.buffered, value)
+	return nil
+}
+
+func (s *OutputStream) writeJson(list []interface{}) error {
+	for i, item := range list {
+		result, err := json.MarshalIndent(item, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		separator := ",\n"
+		if s.count+i == 0 {
+			separator = "[\n"
+		}
+		fmt.Print(separator + "  " + string(result))
+	}
+	return nil
+}
+
+func (s *OutputStream) writeYaml(list []interface{}) error {
+	if len(list) == 0 {
+		return nil
+	}
+	result, err := yaml.Marshal(list)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(result))
+	return nil
+}
+
+func (s *OutputStream) writeSeparated(items interface{}, list []interface{}) error {
+	if s.csv == nil {
+		s.csv = csv.NewWriter(os.Stdout)
+		if output.format == "tsv" {
+			s.csv.Comma = '\t'
+		}
+		s.columns = output.columns
+		if len(s.columns) == 0 {
+			s.columns = columnsFor(items, list)
+		}
+		s.csv.Write(s.columns)
+	}
+	for _, item := range list {
+		s.csv.Write(toRow(item, s.columns))
+	}
+	s.csv.Flush()
+	return s.csv.Error()
+}
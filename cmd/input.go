@@ -0,0 +1,54 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+)
+
+// contentFromFlags returns the content given with a flag like --body or read
+// from the file given with a flag like --body_file, where '-' reads from stdin
+func contentFromFlags(content, file *string, contentFlag, fileFlag string) (string, error) {
+	if content != nil && file != nil {
+		return "", errors.New("only one of --" + contentFlag + " or --" + fileFlag + " can be given")
+	}
+	if content != nil {
+		return *content, nil
+	}
+	if file == nil {
+		return "", errors.New("required flag --" + contentFlag + " or --" + fileFlag + " was empty")
+	}
+	return readFileOrStdin(*file)
+}
+
+// readFileOrStdin returns the content of a file or of stdin if file is '-'
+func readFileOrStdin(file string) (string, error) {
+	var content []byte
+	var err error
+	if file == "-" {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(file)
+	}
+	return string(content), err
+}
@@ -0,0 +1,454 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+	"gopkg.in/yaml.v2"
+)
+
+// integrationService wraps the calls of the vendored ServicesService for one
+// service, options returns empty options for the set call
+type integrationService struct {
+	options func() interface{}
+	set     func(pid string, opts interface{}) error
+	delete  func(pid string) error
+}
+
+var integrationServices = map[string]integrationService{
+	"gitlab-ci": {
+		options: func() interface{} { return &gitlab.SetGitLabCIServiceOptions{} },
+		set: func(pid string, opts interface{}) error {
+			_, err := gitlabClient.Services.SetGitLabCIService(pid, opts.(*gitlab.SetGitLabCIServiceOptions))
+			return err
+		},
+		delete: func(pid string) error {
+			_, err := gitlabClient.Services.DeleteGitLabCIService(pid)
+			return err
+		},
+	},
+	"hipchat": {
+		options: func() interface{} { return &gitlab.SetHipChatServiceOptions{} },
+		set: func(pid string, opts interface{}) error {
+			_, err := gitlabClient.Services.SetHipChatService(pid, opts.(*gitlab.SetHipChatServiceOptions))
+			return err
+		},
+		delete: func(pid string) error {
+			_, err := gitlabClient.Services.DeleteHipChatService(pid)
+			return err
+		},
+	},
+	"drone-ci": {
+		options: func() interface{} { return &gitlab.SetDroneCIServiceOptions{} },
+		set: func(pid string, opts interface{}) error {
+			_, err := gitlabClient.Services.SetDroneCIService(pid, opts.(*gitlab.SetDroneCIServiceOptions))
+			return err
+		},
+		delete: func(pid string) error {
+			_, err := gitlabClient.Services.DeleteDroneCIService(pid)
+			return err
+		},
+	},
+	"slack": {
+		options: func() interface{} { return &gitlab.SetSlackServiceOptions{} },
+		set: func(pid string, opts interface{}) error {
+			_, err := gitlabClient.Services.SetSlackService(pid, opts.(*gitlab.SetSlackServiceOptions))
+			return err
+		},
+		delete: func(pid string) error {
+			_, err := gitlabClient.Services.DeleteSlackService(pid)
+			return err
+		},
+	},
+	"jira": {
+		options: func() interface{} { return &gitlab.SetJiraServiceOptions{} },
+		set: func(pid string, opts interface{}) error {
+			_, err := gitlabClient.Services.SetJiraService(pid, opts.(*gitlab.SetJiraServiceOptions))
+			return err
+		},
+		delete: func(pid string) error {
+			_, err := gitlabClient.Services.DeleteJiraService(pid)
+			return err
+		},
+	},
+}
+
+// integration is the generic representation of a service returned by the
+// API, the vendored types drop most of the properties
+type integration map[string]interface{}
+
+// see https://docs.gitlab.com/ce/api/services.html
+var integrationsCmd = &golabCommand{
+	Parent: projectsCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:     "integrations",
+		Aliases: []string{"services"},
+		Short:   "Manage project integrations",
+		Long: `Get, configure and delete the integrations (services) of a project.
+
+Supported services: ` + strings.Join(integrationServiceNames(), ", "),
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+type integrationsGetFlags struct {
+	Id *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+}
+
+var integrationsGetCmd = &golabCommand{
+	Parent: integrationsCmd.Cmd,
+	Flags:  &integrationsGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get <service>",
+		Short: "Get integration settings",
+		Long:  `Get the settings of an integration of a project, including its properties.`,
+		Args:  cobra.ExactArgs(1),
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*integrationsGetFlags)
+		if _, err := lookupIntegrationService(cmd.Args[0]); err != nil {
+			return err
+		}
+		current, err := getIntegration(*flags.Id, cmd.Args[0])
+		if err != nil {
+			return err
+		}
+		return OutputJson(current)
+	},
+}
+
+var integrationsSetCmd = &golabCommand{
+	Parent: integrationsCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "set",
+		Short: "Configure an integration",
+		Long:  `Configure and activate an integration of a project, each service has its own sub-command and flags`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/services.html#createedit-gitlab-ci-service
+type integrationsSetGitLabCIFlags struct {
+	Id         *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Token      *string `flag_name:"token" short:"t" type:"string" required:"yes" description:"GitLab CI project specific token"`
+	ProjectURL *string `flag_name:"project_url" short:"u" type:"string" required:"yes" description:"http://ci.gitlabexample.com/projects/3"`
+}
+
+var integrationsSetGitLabCICmd = &golabCommand{
+	Parent: integrationsSetCmd.Cmd,
+	Flags:  &integrationsSetGitLabCIFlags{},
+	Opts:   &gitlab.SetGitLabCIServiceOptions{},
+	Cmd: &cobra.Command{
+		Use:   "gitlab-ci",
+		Short: "Configure GitLab CI integration",
+		Long:  `Set GitLab CI service for a project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		return setIntegration(*cmd.Flags.(*integrationsSetGitLabCIFlags).Id, "gitlab-ci", cmd.Opts)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/services.html#createedit-hipchat-service
+type integrationsSetHipChatFlags struct {
+	Id    *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Token *string `flag_name:"token" short:"t" type:"string" required:"yes" description:"Room token"`
+	Room  *string `flag_name:"room" short:"r" type:"string" required:"no" description:"Room name or ID"`
+}
+
+var integrationsSetHipChatCmd = &golabCommand{
+	Parent: integrationsSetCmd.Cmd,
+	Flags:  &integrationsSetHipChatFlags{},
+	Opts:   &gitlab.SetHipChatServiceOptions{},
+	Cmd: &cobra.Command{
+		Use:   "hipchat",
+		Short: "Configure HipChat integration",
+		Long:  `Set HipChat service for a project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		return setIntegration(*cmd.Flags.(*integrationsSetHipChatFlags).Id, "hipchat", cmd.Opts)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/services.html#createedit-drone-ci-service
+type integrationsSetDroneCIFlags struct {
+	Id                    *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Token                 *string `flag_name:"token" short:"t" type:"string" required:"yes" description:"Drone CI project specific token"`
+	DroneURL              *string `flag_name:"drone_url" short:"u" type:"string" required:"yes" description:"http://drone.example.com"`
+	EnableSSLVerification *bool   `flag_name:"enable_ssl_verification" type:"boolean" required:"no" description:"Enable SSL verification"`
+}
+
+var integrationsSetDroneCICmd = &golabCommand{
+	Parent: integrationsSetCmd.Cmd,
+	Flags:  &integrationsSetDroneCIFlags{},
+	Opts:   &gitlab.SetDroneCIServiceOptions{},
+	Cmd: &cobra.Command{
+		Use:   "drone-ci",
+		Short: "Configure Drone CI integration",
+		Long:  `Set Drone CI service for a project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		return setIntegration(*cmd.Flags.(*integrationsSetDroneCIFlags).Id, "drone-ci", cmd.Opts)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/services.html#createedit-slack-service
+type integrationsSetSlackFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	WebHook  *string `flag_name:"webhook" short:"w" type:"string" required:"yes" description:"https://hooks.slack.com/services/..."`
+	Username *string `flag_name:"username" short:"u" type:"string" required:"no" description:"username"`
+	Channel  *string `flag_name:"channel" short:"c" type:"string" required:"no" description:"Default channel to use if others are not configured"`
+}
+
+var integrationsSetSlackCmd = &golabCommand{
+	Parent: integrationsSetCmd.Cmd,
+	Flags:  &integrationsSetSlackFlags{},
+	Opts:   &gitlab.SetSlackServiceOptions{},
+	Cmd: &cobra.Command{
+		Use:   "slack",
+		Short: "Configure Slack integration",
+		Long:  `Set Slack service for a project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		return setIntegration(*cmd.Flags.(*integrationsSetSlackFlags).Id, "slack", cmd.Opts)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/services.html#edit-jira-service
+type integrationsSetJiraFlags struct {
+	Id                    *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	URL                   *string `flag_name:"url" short:"u" type:"string" required:"yes" description:"The URL to the JIRA project which is being linked to this GitLab project, e.g., https://jira.example.com"`
+	ProjectKey            *string `flag_name:"project_key" short:"k" type:"string" required:"yes" description:"The short identifier for your JIRA project, all uppercase, e.g., PROJ"`
+	Username              *string `flag_name:"username" type:"string" required:"no" description:"The username of the user created to be used with GitLab/JIRA"`
+	Password              *string `flag_name:"password" type:"string" required:"no" description:"The password of the user created to be used with GitLab/JIRA"`
+	JiraIssueTransitionID *string `flag_name:"jira_issue_transition_id" type:"string" required:"no" description:"The ID of a transition that moves issues to a closed state"`
+}
+
+var integrationsSetJiraCmd = &golabCommand{
+	Parent: integrationsSetCmd.Cmd,
+	Flags:  &integrationsSetJiraFlags{},
+	Opts:   &gitlab.SetJiraServiceOptions{},
+	Cmd: &cobra.Command{
+		Use:   "jira",
+		Short: "Configure Jira integration",
+		Long:  `Set JIRA service for a project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		return setIntegration(*cmd.Flags.(*integrationsSetJiraFlags).Id, "jira", cmd.Opts)
+	},
+}
+
+type integrationsDeleteFlags struct {
+	Id *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+}
+
+var integrationsDeleteCmd = &golabCommand{
+	Parent: integrationsCmd.Cmd,
+	Flags:  &integrationsDeleteFlags{},
+	Cmd: &cobra.Command{
+		Use:   "delete <service>",
+		Short: "Delete an integration",
+		Long:  `Delete the settings of an integration and deactivate it for a project.`,
+		Args:  cobra.ExactArgs(1),
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*integrationsDeleteFlags)
+		service, err := lookupIntegrationService(cmd.Args[0])
+		if err != nil {
+			return err
+		}
+		return service.delete(*flags.Id)
+	},
+}
+
+type integrationsApplyFlags struct {
+	Group  *string `flag_name:"group" short:"g" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the group whose projects are configured"`
+	File   *string `flag_name:"file" short:"f" type:"string" required:"yes" description:"YAML file with the integration, '-' reads from stdin"`
+	DryRun *bool   `flag_name:"dry_run" type:"boolean" required:"no" description:"Only print the projects that would be updated"`
+}
+
+// integrationProfile is the content of the file read by 'integrations apply'
+type integrationProfile struct {
+	Service    string                 `yaml:"service"`
+	Properties map[string]interface{} `yaml:"properties"`
+}
+
+var integrationsApplyCmd = &golabCommand{
+	Parent: integrationsCmd.Cmd,
+	Flags:  &integrationsApplyFlags{},
+	Cmd: &cobra.Command{
+		Use:   "apply",
+		Short: "Configure an integration for all projects of a group",
+		Long: `Configure the same integration for every project of a group and print the projects that were updated.
+
+Projects whose integration is already active with the given properties are skipped. GitLab does not return secrets like passwords, so integrations with such properties are always updated.
+
+Example file:
+
+    service: slack
+    properties:
+      webhook: https://hooks.slack.com/services/...
+      username: gitlab
+      channel: dev`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*integrationsApplyFlags)
+		content, err := readFileOrStdin(*flags.File)
+		if err != nil {
+			return err
+		}
+		profile := &integrationProfile{}
+		if err := yaml.Unmarshal([]byte(content), profile); err != nil {
+			return err
+		}
+		return applyIntegration(*flags.Group, profile, flags.DryRun != nil && *flags.DryRun)
+	},
+}
+
+// applyIntegration configures the integration of the profile for all
+// projects of the group that are not configured accordingly yet
+func applyIntegration(group string, profile *integrationProfile, dryRun bool) error {
+	service, err := lookupIntegrationService(profile.Service)
+	if err != nil {
+		return err
+	}
+	opts, err := integrationOptions(service, profile.Properties)
+	if err != nil {
+		return err
+	}
+	var projects []*gitlab.Project
+	listOpts := &gitlab.ListGroupProjectsOptions{}
+	err = forEachPage(&listOpts.ListOptions, func() (*gitlab.Response, error) {
+		page, resp, err := gitlabClient.Groups.ListGroupProjects(group, listOpts)
+		projects = append(projects, page...)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	for _, project := range projects {
+		current, err := getIntegration(project.PathWithNamespace, profile.Service)
+		if err != nil {
+			return err
+		}
+		if current.matches(profile.Properties) {
+			continue
+		}
+		if !dryRun {
+			if err := service.set(project.PathWithNamespace, opts); err != nil {
+				return err
+			}
+		}
+		printSyncAction(dryRun, "update", project.PathWithNamespace)
+	}
+	return nil
+}
+
+// integrationOptions converts the properties into the options of the
+// service, properties are matched by the names of the options
+func integrationOptions(service integrationService, properties map[string]interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(properties)
+	if err != nil {
+		return nil, err
+	}
+	opts := service.options()
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(opts); err != nil {
+		return nil, fmt.Errorf("invalid integration properties: %s", err)
+	}
+	return opts, nil
+}
+
+// matches returns true, if the integration is active and has the given
+// properties
+func (i integration) matches(properties map[string]interface{}) bool {
+	if active, _ := i["active"].(bool); !active {
+		return false
+	}
+	current, _ := i["properties"].(map[string]interface{})
+	for key, value := range properties {
+		if fmt.Sprint(current[key]) != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func setIntegration(pid string, name string, opts interface{}) error {
+	if err := integrationServices[name].set(pid, opts); err != nil {
+		return err
+	}
+	current, err := getIntegration(pid, name)
+	if err != nil {
+		return err
+	}
+	return OutputJson(current)
+}
+
+func getIntegration(pid string, name string) (integration, error) {
+	current := integration{}
+	_, err := gitlabRequest("GET", projectPath(pid, "/services/%s", name), nil, &current)
+	return current, err
+}
+
+func lookupIntegrationService(name string) (integrationService, error) {
+	service, ok := integrationServices[name]
+	if !ok {
+		return service, fmt.Errorf("unsupported service %s, use one of %s", name, strings.Join(integrationServiceNames(), ", "))
+	}
+	return service, nil
+}
+
+func integrationServiceNames() []string {
+	var names []string
+	for name := range integrationServices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	integrationsCmd.Init()
+	integrationsGetCmd.Init()
+	integrationsSetCmd.Init()
+	integrationsSetGitLabCICmd.Init()
+	integrationsSetHipChatCmd.Init()
+	integrationsSetDroneCICmd.Init()
+	integrationsSetSlackCmd.Init()
+	integrationsSetJiraCmd.Init()
+	integrationsDeleteCmd.Init()
+	integrationsApplyCmd.Init()
+}
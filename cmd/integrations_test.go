@@ -0,0 +1,109 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("integrations command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `set slack` sub command is executed", func() {
+		It("configures the service and outputs the integration", func() {
+			var body map[string]interface{}
+			mux.HandleFunc("/api/v4/projects/42/services/slack", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "PUT" {
+					json.NewDecoder(r.Body).Decode(&body)
+					return
+				}
+				fmt.Fprint(w, `{"title":"Slack","active":true,"properties":{"channel":"dev"}}`)
+			})
+
+			out, _, err := executeCommand(RootCmd, "project", "integrations", "set", "slack", "-i", "42", "-w", "https://hooks.slack.com/x", "-c", "dev")
+			Expect(err).To(BeNil())
+			Expect(body).To(Equal(map[string]interface{}{"webhook": "https://hooks.slack.com/x", "channel": "dev"}))
+			Expect(out).To(ContainSubstring(`"title": "Slack"`))
+		})
+	})
+
+	Context("when the `apply` sub command is executed", func() {
+		It("updates the projects of the group whose integration differs", func() {
+			mux.HandleFunc("/api/v4/groups/my-group/projects", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"id":1,"path_with_namespace":"my-group/a"},{"id":2,"path_with_namespace":"my-group/b"},{"id":3,"path_with_namespace":"my-group/c"}]`)
+			})
+			updated := []string{}
+			handler := func(properties string) func(w http.ResponseWriter, r *http.Request) {
+				return func(w http.ResponseWriter, r *http.Request) {
+					if r.Method == "PUT" {
+						updated = append(updated, r.URL.Path)
+						return
+					}
+					fmt.Fprint(w, properties)
+				}
+			}
+			mux.HandleFunc("/api/v4/projects/my-group/a/services/slack", handler(`{"active":true,"properties":{"webhook":"https://hooks.slack.com/x","channel":"dev"}}`))
+			mux.HandleFunc("/api/v4/projects/my-group/b/services/slack", handler(`{"active":false,"properties":{}}`))
+			mux.HandleFunc("/api/v4/projects/my-group/c/services/slack", handler(`{"active":true,"properties":{"webhook":"https://hooks.slack.com/x","channel":"ops"}}`))
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			w.WriteString("service: slack\nproperties:\n  webhook: https://hooks.slack.com/x\n  channel: dev\n")
+			w.Close()
+			os.Stdin = r
+
+			out, _, err := executeCommand(RootCmd, "project", "integrations", "apply", "-g", "my-group", "-f", "-")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("updated my-group/b\nupdated my-group/c"))
+			Expect(updated).To(Equal([]string{"/api/v4/projects/my-group/b/services/slack", "/api/v4/projects/my-group/c/services/slack"}))
+		})
+	})
+})
+
+var _ = Describe("integrationOptions", func() {
+	It("rejects unknown properties", func() {
+		_, err := integrationOptions(integrationServices["slack"], map[string]interface{}{"room": "dev"})
+		Expect(err).NotTo(BeNil())
+	})
+})
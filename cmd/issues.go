@@ -0,0 +1,548 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// see https://docs.gitlab.com/ce/api/issues.html
+var issuesCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "issues",
+		Aliases: []string{"issue"},
+		Short:   "Manage issues",
+		Long:    `Show, create, edit, close and delete issues and track the time spent on them`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#list-issues
+type issuesListFlags struct {
+	State           *string `flag_name:"state" type:"string" required:"no" description:"Return all issues or just those that are opened or closed"`
+	Labels          *string `flag_name:"labels" type:"[]string" transform:"string2Labels" required:"no" description:"Comma-separated list of label names, issues must have all labels to be returned. No+Label lists all issues with no labels"`
+	Milestone       *string `flag_name:"milestone" type:"string" required:"no" description:"The milestone title"`
+	Scope           *string `flag_name:"scope" type:"string" required:"no" description:"Return issues for the given scope: created-by-me, assigned-to-me or all. Defaults to created-by-me"`
+	AuthorID        *int    `flag_name:"author_id" type:"integer" required:"no" description:"Return issues created by the given user id. Combine with scope=all or scope=assigned-to-me"`
+	AssigneeID      *int    `flag_name:"assignee_id" type:"integer" required:"no" description:"Return issues assigned to the given user id"`
+	MyReactionEmoji *string `flag_name:"my_reaction_emoji" type:"string" required:"no" description:"Return issues reacted by the authenticated user by the given emoji"`
+	IIDs            []int   `flag_name:"iids" type:"Array[integer]" required:"no" description:"Return only the issues having the given iid"`
+	OrderBy         *string `flag_name:"order_by" type:"string" required:"no" description:"Return issues ordered by created_at or updated_at fields. Default is created_at"`
+	Sort            *string `flag_name:"sort" type:"string" required:"no" description:"Return issues sorted in asc or desc order. Default is desc"`
+	Search          *string `flag_name:"search" type:"string" required:"no" description:"Search issues against their title and description"`
+}
+
+var issuesListCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesListFlags{},
+	Opts:   &gitlab.ListIssuesOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List issues",
+		Long:  `Get all issues the authenticated user has access to. By default it returns only issues created by the current user. To get all issues, use parameter scope=all.`,
+	},
+	Run: func(cmd golabCommand) error {
+		opts := cmd.Opts.(*gitlab.ListIssuesOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			return gitlabClient.Issues.ListIssues(opts)
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#list-project-issues
+type issuesListForProjectFlags struct {
+	Id              *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IIDs            []int   `flag_name:"iids" type:"Array[integer]" required:"no" description:"Return only the issues having the given iid"`
+	State           *string `flag_name:"state" type:"string" required:"no" description:"Return all issues or just those that are opened or closed"`
+	Labels          *string `flag_name:"labels" type:"[]string" transform:"string2Labels" required:"no" description:"Comma-separated list of label names, issues must have all labels to be returned. No+Label lists all issues with no labels"`
+	Milestone       *string `flag_name:"milestone" type:"string" required:"no" description:"The milestone title"`
+	Scope           *string `flag_name:"scope" type:"string" required:"no" description:"Return issues for the given scope: created-by-me, assigned-to-me or all"`
+	AuthorID        *int    `flag_name:"author_id" type:"integer" required:"no" description:"Return issues created by the given user id"`
+	AssigneeID      *int    `flag_name:"assignee_id" type:"integer" required:"no" description:"Return issues assigned to the given user id"`
+	MyReactionEmoji *string `flag_name:"my_reaction_emoji" type:"string" required:"no" description:"Return issues reacted by the authenticated user by the given emoji"`
+	OrderBy         *string `flag_name:"order_by" type:"string" required:"no" description:"Return issues ordered by created_at or updated_at fields. Default is created_at"`
+	Sort            *string `flag_name:"sort" type:"string" required:"no" description:"Return issues sorted in asc or desc order. Default is desc"`
+	Search          *string `flag_name:"search" type:"string" required:"no" description:"Search project issues against their title and description"`
+	CreatedAfter    *string `flag_name:"created_after" type:"datetime" transform:"string2Time" required:"no" description:"Return issues created after the given date (inclusive), format YYYY-MM-DD"`
+	CreatedBefore   *string `flag_name:"created_before" type:"datetime" transform:"string2Time" required:"no" description:"Return issues created before the given date (inclusive), format YYYY-MM-DD"`
+}
+
+var issuesListForProjectCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesListForProjectFlags{},
+	Opts:   &gitlab.ListProjectIssuesOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "project-ls",
+		Short: "List project issues",
+		Long:  `Get a list of a project's issues.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesListForProjectFlags)
+		opts := cmd.Opts.(*gitlab.ListProjectIssuesOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			return gitlabClient.Issues.ListProjectIssues(*flags.Id, opts)
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#single-issue
+type issuesGetFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+}
+
+var issuesGetCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get single issue",
+		Long:  `Get a single project issue.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesGetFlags)
+		issue, _, err := gitlabClient.Issues.GetIssue(*flags.Id, *flags.IssueIid)
+		if err != nil {
+			return err
+		}
+		return OutputJson(issue)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#new-issue
+type issuesCreateFlags struct {
+	Id                                 *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Title                              *string `flag_name:"title" short:"t" type:"string" required:"yes" description:"The title of an issue"`
+	Description                        *string `flag_name:"description" short:"d" type:"string" required:"no" description:"The description of an issue"`
+	Confidential                       *bool   `flag_name:"confidential" short:"c" type:"boolean" required:"no" description:"Set an issue to be confidential. Default is false."`
+	AssigneeIDs                        []int   `flag_name:"assignee_ids" short:"a" type:"Array[integer]" required:"no" description:"The ID of the users to assign issue"`
+	MilestoneID                        *int    `flag_name:"milestone_id" short:"m" type:"integer" required:"no" description:"The global ID of a milestone to assign issue"`
+	Labels                             *string `flag_name:"labels" short:"l" type:"[]string" transform:"string2Labels" required:"no" description:"Comma-separated label names for an issue"`
+	CreatedAt                          *string `flag_name:"created_at" type:"string" transform:"string2Time" required:"no" description:"Date time string, ISO 8601 formatted, e.g. 2016-03-11 (requires admin or project owner rights)"`
+	DueDate                            *string `flag_name:"due_date" type:"string" transform:"string2IsoTime" required:"no" description:"Date time string in the format YEAR-MONTH-DAY, e.g. 2016-03-11"`
+	MergeRequestToResolveDiscussionsOf *int    `flag_name:"merge_request_to_resolve_discussions_of" type:"integer" required:"no" description:"The IID of a merge request in which to resolve all issues. This will fill the issue with a default description and mark all discussions as resolved. When passing a description or title, these values will take precedence over the default values."`
+	DiscussionToResolve                *string `flag_name:"discussion_to_resolve" type:"string" required:"no" description:"The ID of a discussion to resolve. This will fill in the issue with a default description and mark the discussion as resolved. Use in combination with merge_request_to_resolve_discussions_of."`
+}
+
+var issuesCreateCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesCreateFlags{},
+	Opts:   &gitlab.CreateIssueOptions{},
+	Cmd: &cobra.Command{
+		Use:   "create",
+		Short: "New issue",
+		Long:  `Creates a new project issue.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesCreateFlags)
+		opts := cmd.Opts.(*gitlab.CreateIssueOptions)
+		issue, _, err := gitlabClient.Issues.CreateIssue(*flags.Id, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(issue)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#edit-issue
+type issuesEditFlags struct {
+	Id               *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid         *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+	Title            *string `flag_name:"title" short:"t" type:"string" required:"no" description:"The title of an issue"`
+	Description      *string `flag_name:"description" short:"d" type:"string" required:"no" description:"The description of an issue"`
+	Confidential     *bool   `flag_name:"confidential" short:"c" type:"boolean" required:"no" description:"Updates an issue to be confidential"`
+	AssigneeID       *int    `flag_name:"assignee_id" short:"a" type:"integer" required:"no" description:"The ID of the user to assign the issue to"`
+	MilestoneID      *int    `flag_name:"milestone_id" short:"m" type:"integer" required:"no" description:"The global ID of a milestone to assign the issue to"`
+	Labels           *string `flag_name:"labels" short:"l" type:"[]string" transform:"string2Labels" required:"no" description:"Comma-separated label names for an issue"`
+	StateEvent       *string `flag_name:"state_event" type:"string" required:"no" description:"The state event of an issue. Set close to close the issue and reopen to reopen it"`
+	UpdatedAt        *string `flag_name:"updated_at" type:"string" transform:"string2Time" required:"no" description:"Date time string, ISO 8601 formatted, e.g. 2016-03-11 (requires admin or project owner rights)"`
+	DueDate          *string `flag_name:"due_date" type:"string" transform:"string2IsoTime" required:"no" description:"Date time string in the format YEAR-MONTH-DAY, e.g. 2016-03-11"`
+	DiscussionLocked *bool   `flag_name:"discussion_locked" type:"boolean" required:"no" description:"Flag indicating if the issue's discussion is locked. If the discussion is locked only project members can add or edit comments."`
+}
+
+var issuesEditCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesEditFlags{},
+	Opts:   &gitlab.UpdateIssueOptions{},
+	Cmd: &cobra.Command{
+		Use:   "edit",
+		Short: "Edit issue",
+		Long:  `Updates an existing project issue. This call is also used to mark an issue as closed.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesEditFlags)
+		opts := cmd.Opts.(*gitlab.UpdateIssueOptions)
+		issue, _, err := gitlabClient.Issues.UpdateIssue(*flags.Id, *flags.IssueIid, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(issue)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#edit-issue
+type issuesCloseFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+}
+
+var issuesCloseCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesCloseFlags{},
+	Cmd: &cobra.Command{
+		Use:   "close",
+		Short: "Close issue",
+		Long:  `Closes an issue, same as 'edit --state_event close'.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesCloseFlags)
+		return updateIssueState(*flags.Id, *flags.IssueIid, "close")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#edit-issue
+type issuesReopenFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+}
+
+var issuesReopenCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesReopenFlags{},
+	Cmd: &cobra.Command{
+		Use:   "reopen",
+		Short: "Reopen issue",
+		Long:  `Reopens a closed issue, same as 'edit --state_event reopen'.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesReopenFlags)
+		return updateIssueState(*flags.Id, *flags.IssueIid, "reopen")
+	},
+}
+
+func updateIssueState(pid string, issueIid int, stateEvent string) error {
+	opts := &gitlab.UpdateIssueOptions{StateEvent: &stateEvent}
+	issue, _, err := gitlabClient.Issues.UpdateIssue(pid, issueIid, opts)
+	if err != nil {
+		return err
+	}
+	return OutputJson(issue)
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#delete-an-issue
+type issuesDeleteFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+}
+
+var issuesDeleteCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesDeleteFlags{},
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Delete an issue",
+		Long:  `Only for admins and project owners. Soft deletes the issue in question.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesDeleteFlags)
+		_, err := gitlabClient.Issues.DeleteIssue(*flags.Id, *flags.IssueIid)
+		return err
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#move-an-issue
+type issuesMoveFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid    *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+	ToProjectID *int    `flag_name:"to_project_id" short:"t" type:"integer" required:"yes" description:"The ID of the new project"`
+}
+
+// moveIssueOptions represents the options of the move issue API, which is not
+// supported by the vendored IssuesService
+type moveIssueOptions struct {
+	ToProjectID *int `url:"to_project_id,omitempty" json:"to_project_id,omitempty"`
+}
+
+var issuesMoveCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesMoveFlags{},
+	Opts:   &moveIssueOptions{},
+	Cmd: &cobra.Command{
+		Use:   "move",
+		Short: "Move an issue",
+		Long: `Moves an issue to a different project. If the target project equals the source project or the user has insufficient permissions, an error is returned.
+
+If a given label and/or milestone with the same name also exists in the target project, it will then be assigned to the issue that is being moved.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesMoveFlags)
+		opts := cmd.Opts.(*moveIssueOptions)
+		issue := new(gitlab.Issue)
+		_, err := gitlabRequest("POST", projectPath(*flags.Id, "/issues/%d/move", *flags.IssueIid), opts, issue)
+		if err != nil {
+			return err
+		}
+		return OutputJson(issue)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#subscribe-to-an-issue
+type issuesSubscribeFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+}
+
+var issuesSubscribeCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesSubscribeFlags{},
+	Cmd: &cobra.Command{
+		Use:   "subscribe",
+		Short: "Subscribe to an issue",
+		Long:  `Subscribes the authenticated user to an issue to receive notifications. If the user is already subscribed to the issue, the status code 304 is returned.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesSubscribeFlags)
+		issue := new(gitlab.Issue)
+		resp, err := gitlabRequest("POST", projectPath(*flags.Id, "/issues/%d/subscribe", *flags.IssueIid), nil, issue)
+		if resp != nil && resp.StatusCode == 304 {
+			return errors.New("304: the user was already subscribed to the issue")
+		}
+		if err != nil {
+			return err
+		}
+		return OutputJson(issue)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#unsubscribe-from-an-issue
+type issuesUnsubscribeFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+}
+
+var issuesUnsubscribeCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesUnsubscribeFlags{},
+	Cmd: &cobra.Command{
+		Use:   "unsubscribe",
+		Short: "Unsubscribe from an issue",
+		Long:  `Unsubscribes the authenticated user from the issue to not receive notifications from it. If the user is not subscribed to the issue, the status code 304 is returned.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesUnsubscribeFlags)
+		issue := new(gitlab.Issue)
+		resp, err := gitlabRequest("POST", projectPath(*flags.Id, "/issues/%d/unsubscribe", *flags.IssueIid), nil, issue)
+		if resp != nil && resp.StatusCode == 304 {
+			return errors.New("304: the user was not subscribed to the issue")
+		}
+		if err != nil {
+			return err
+		}
+		return OutputJson(issue)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#create-a-todo
+type issuesCreateTodoFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+}
+
+var issuesCreateTodoCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesCreateTodoFlags{},
+	Cmd: &cobra.Command{
+		Use:     "create-todo",
+		Aliases: []string{"todo"},
+		Short:   "Create a todo",
+		Long:    `Manually creates a todo for the current user on an issue. If there already exists a todo for the user on that issue, status code 304 is returned.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesCreateTodoFlags)
+		todo := new(gitlab.Todo)
+		resp, err := gitlabRequest("POST", projectPath(*flags.Id, "/issues/%d/todo", *flags.IssueIid), nil, todo)
+		if resp != nil && resp.StatusCode == 304 {
+			return errors.New("304: there already exists a todo on that issue for the current user")
+		}
+		if err != nil {
+			return err
+		}
+		return OutputJson(todo)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#set-a-time-estimate-for-an-issue
+type issuesSetTimeEstimateFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+	Duration *string `flag_name:"duration" short:"d" type:"string" required:"yes" description:"The duration in human format. e.g: 3h30m"`
+}
+
+var issuesSetTimeEstimateCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesSetTimeEstimateFlags{},
+	Opts:   &gitlab.SetTimeEstimateOptions{},
+	Cmd: &cobra.Command{
+		Use:   "set-time-estimate",
+		Short: "Set a time estimate for an issue",
+		Long:  `Sets an estimated time of work for this issue.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesSetTimeEstimateFlags)
+		opts := cmd.Opts.(*gitlab.SetTimeEstimateOptions)
+		timeStats, _, err := gitlabClient.Issues.SetTimeEstimate(*flags.Id, *flags.IssueIid, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(timeStats)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#reset-the-time-estimate-for-an-issue
+type issuesResetTimeEstimateFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+}
+
+var issuesResetTimeEstimateCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesResetTimeEstimateFlags{},
+	Cmd: &cobra.Command{
+		Use:   "reset-time-estimate",
+		Short: "Reset the time estimate for an issue",
+		Long:  `Resets the estimated time for this issue to 0 seconds.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesResetTimeEstimateFlags)
+		timeStats, _, err := gitlabClient.Issues.ResetTimeEstimate(*flags.Id, *flags.IssueIid)
+		if err != nil {
+			return err
+		}
+		return OutputJson(timeStats)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#add-spent-time-for-an-issue
+type issuesAddSpentTimeFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+	Duration *string `flag_name:"duration" short:"d" type:"string" required:"yes" description:"The duration in human format. e.g: 3h30m"`
+}
+
+var issuesAddSpentTimeCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesAddSpentTimeFlags{},
+	Opts:   &gitlab.AddSpentTimeOptions{},
+	Cmd: &cobra.Command{
+		Use:   "add-spent-time",
+		Short: "Add spent time for an issue",
+		Long:  `Adds spent time for this issue`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesAddSpentTimeFlags)
+		opts := cmd.Opts.(*gitlab.AddSpentTimeOptions)
+		timeStats, _, err := gitlabClient.Issues.AddSpentTime(*flags.Id, *flags.IssueIid, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(timeStats)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#reset-spent-time-for-an-issue
+type issuesResetSpentTimeFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+}
+
+var issuesResetSpentTimeCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesResetSpentTimeFlags{},
+	Cmd: &cobra.Command{
+		Use:   "reset-spent-time",
+		Short: "Reset spent time for an issue",
+		Long:  `Resets the total spent time for this issue to 0 seconds.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesResetSpentTimeFlags)
+		timeStats, _, err := gitlabClient.Issues.ResetSpentTime(*flags.Id, *flags.IssueIid)
+		if err != nil {
+			return err
+		}
+		return OutputJson(timeStats)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/issues.html#get-time-tracking-stats
+type issuesGetTimeTrackingStatsFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"yes" description:"The internal ID of a project's issue"`
+}
+
+var issuesGetTimeTrackingStatsCmd = &golabCommand{
+	Parent: issuesCmd.Cmd,
+	Flags:  &issuesGetTimeTrackingStatsFlags{},
+	Cmd: &cobra.Command{
+		Use:   "time-tracking-stats",
+		Short: "Get time tracking stats",
+		Long:  `Get time tracking stats`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*issuesGetTimeTrackingStatsFlags)
+		stats, _, err := gitlabClient.Issues.GetTimeSpent(*flags.Id, *flags.IssueIid)
+		if err != nil {
+			return err
+		}
+		return OutputJson(stats)
+	},
+}
+
+func init() {
+	AddDefaultColumns(gitlab.Issue{}, "iid", "title", "state", "author.username", "web_url")
+	issuesCmd.Init()
+	issuesListCmd.Init()
+	issuesListForProjectCmd.Init()
+	issuesGetCmd.Init()
+	issuesCreateCmd.Init()
+	issuesEditCmd.Init()
+	issuesCloseCmd.Init()
+	issuesReopenCmd.Init()
+	issuesDeleteCmd.Init()
+	issuesMoveCmd.Init()
+	issuesSubscribeCmd.Init()
+	issuesUnsubscribeCmd.Init()
+	issuesCreateTodoCmd.Init()
+	issuesSetTimeEstimateCmd.Init()
+	issuesResetTimeEstimateCmd.Init()
+	issuesAddSpentTimeCmd.Init()
+	issuesResetSpentTimeCmd.Init()
+	issuesGetTimeTrackingStatsCmd.Init()
+}
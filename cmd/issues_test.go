@@ -0,0 +1,84 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("issues command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when the `create` sub command is executed", func() {
+		It("sends the mapped flags", func() {
+			body := ""
+			mux.HandleFunc("/api/v4/projects/1/issues", func(w http.ResponseWriter, r *http.Request) {
+				bodyBytes, _ := ioutil.ReadAll(r.Body)
+				body = string(bodyBytes)
+				fmt.Fprint(w, `{"iid":3,"title":"broken build"}`)
+			})
+			_, _, err := executeCommand(RootCmd, "issues", "create", "-i", "1", "-t", "broken build", "-l", "bug,ci", "--due_date", "2018-03-11")
+			Expect(err).To(BeNil())
+			Expect(body).To(Equal(`{"title":"broken build","labels":"bug,ci","due_date":"2018-03-11"}`))
+		})
+	})
+
+	Context("when the `close` sub command is executed", func() {
+		It("updates the state of the issue", func() {
+			method := ""
+			body := ""
+			mux.HandleFunc("/api/v4/projects/1/issues/3", func(w http.ResponseWriter, r *http.Request) {
+				method = r.Method
+				bodyBytes, _ := ioutil.ReadAll(r.Body)
+				body = string(bodyBytes)
+				fmt.Fprint(w, `{"iid":3,"state":"closed"}`)
+			})
+			stdout, _, err := executeCommand(RootCmd, "issues", "close", "-i", "1", "-n", "3")
+			Expect(err).To(BeNil())
+			Expect(method).To(Equal("PUT"))
+			Expect(body).To(Equal(`{"state_event":"close"}`))
+			Expect(stdout).To(ContainSubstring(`"state": "closed"`))
+		})
+	})
+})
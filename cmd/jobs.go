@@ -0,0 +1,382 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// see https://docs.gitlab.com/ce/api/jobs.html
+var jobsCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "jobs",
+		Aliases: []string{"job"},
+		Short:   "Manage jobs",
+		Long:    `List, retry, cancel, erase and play CI jobs, show their logs and download their artifacts`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/jobs.html#list-project-jobs
+type jobsListFlags struct {
+	Id         *string   `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	PipelineId *int      `flag_name:"pipeline_id" short:"p" type:"integer" required:"no" description:"Only list the jobs of the pipeline with this ID"`
+	Scope      *[]string `flag_name:"scope" short:"s" type:"string or array of strings" required:"no" description:"The scope of jobs to show, one or array of: created, pending, running, failed, success, canceled, skipped, or manual. All jobs are returned if scope is not provided."`
+}
+
+var jobsListCmd = &golabCommand{
+	Parent: jobsCmd.Cmd,
+	Flags:  &jobsListFlags{},
+	Opts:   &gitlab.ListJobsOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List project or pipeline jobs",
+		Long:    `Get a list of jobs in a project or, if --pipeline_id is given, of a pipeline`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*jobsListFlags)
+		opts := cmd.Opts.(*gitlab.ListJobsOptions)
+		if flags.Scope != nil {
+			for _, scope := range *flags.Scope {
+				opts.Scope = append(opts.Scope, gitlab.BuildState(scope))
+			}
+		}
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			if flags.PipelineId != nil {
+				return gitlabClient.Jobs.ListPipelineJobs(*flags.Id, *flags.PipelineId, opts)
+			}
+			return gitlabClient.Jobs.ListProjectJobs(*flags.Id, opts)
+		})
+	},
+}
+
+// jobFlags are the flags of all commands that work on a single job
+type jobFlags struct {
+	Id    *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	JobId *int    `flag_name:"job_id" short:"j" type:"integer" required:"yes" description:"The ID of a job"`
+}
+
+// see https://docs.gitlab.com/ce/api/jobs.html#get-a-single-job
+var jobsGetCmd = &golabCommand{
+	Parent: jobsCmd.Cmd,
+	Flags:  &jobFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get a single job",
+		Long:  `Get a single job of a project`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*jobFlags)
+		job, _, err := gitlabClient.Jobs.GetJob(*flags.Id, *flags.JobId)
+		if err != nil {
+			return err
+		}
+		return OutputJson(job)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/jobs.html#retry-a-job
+var jobsRetryCmd = &golabCommand{
+	Parent: jobsCmd.Cmd,
+	Flags:  &jobFlags{},
+	Cmd: &cobra.Command{
+		Use:   "retry",
+		Short: "Retry a job",
+		Long:  `Retry a single job of a project`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*jobFlags)
+		job, _, err := gitlabClient.Jobs.RetryJob(*flags.Id, *flags.JobId)
+		if err != nil {
+			return err
+		}
+		return OutputJson(job)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/jobs.html#cancel-a-job
+var jobsCancelCmd = &golabCommand{
+	Parent: jobsCmd.Cmd,
+	Flags:  &jobFlags{},
+	Cmd: &cobra.Command{
+		Use:   "cancel",
+		Short: "Cancel a job",
+		Long:  `Cancel a single job of a project`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*jobFlags)
+		job, _, err := gitlabClient.Jobs.CancelJob(*flags.Id, *flags.JobId)
+		if err != nil {
+			return err
+		}
+		return OutputJson(job)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/jobs.html#erase-a-job
+var jobsEraseCmd = &golabCommand{
+	Parent: jobsCmd.Cmd,
+	Flags:  &jobFlags{},
+	Cmd: &cobra.Command{
+		Use:   "erase",
+		Short: "Erase a job",
+		Long:  `Erase a single job of a project (remove job artifacts and a job trace)`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*jobFlags)
+		job, _, err := gitlabClient.Jobs.EraseJob(*flags.Id, *flags.JobId)
+		if err != nil {
+			return err
+		}
+		return OutputJson(job)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/jobs.html#play-a-job
+var jobsPlayCmd = &golabCommand{
+	Parent: jobsCmd.Cmd,
+	Flags:  &jobFlags{},
+	Cmd: &cobra.Command{
+		Use:   "play",
+		Short: "Play a job",
+		Long:  `Triggers a manual action to start a job`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*jobFlags)
+		job, _, err := gitlabClient.Jobs.PlayJob(*flags.Id, *flags.JobId)
+		if err != nil {
+			return err
+		}
+		return OutputJson(job)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/jobs.html#get-a-trace-file
+type jobsTraceFlags struct {
+	Id       *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	JobId    *int    `flag_name:"job_id" short:"j" type:"integer" required:"yes" description:"The ID of a job"`
+	Follow   *bool   `flag_name:"follow" short:"f" type:"boolean" required:"no" description:"Keep printing the log as it grows until the job finishes"`
+	Interval *int    `flag_name:"interval" type:"integer" required:"no" description:"Seconds to wait between two log requests with --follow (default: 2)"`
+}
+
+var jobsTraceCmd = &golabCommand{
+	Parent: jobsCmd.Cmd,
+	Flags:  &jobsTraceFlags{},
+	Cmd: &cobra.Command{
+		Use:   "trace",
+		Short: "Get the log of a job",
+		Long: `Print the log (trace) of a job.
+
+With --follow, the log is polled and new output is printed like 'tail -f' until the job is finished.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*jobsTraceFlags)
+		if flags.Follow == nil || !*flags.Follow {
+			trace, _, err := gitlabClient.Jobs.GetTraceFile(*flags.Id, *flags.JobId)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(os.Stdout, trace)
+			return err
+		}
+		interval := 2 * time.Second
+		if flags.Interval != nil && *flags.Interval > 0 {
+			interval = time.Duration(*flags.Interval) * time.Second
+		}
+		return followTrace(*flags.Id, *flags.JobId, interval)
+	},
+}
+
+// followTrace prints the trace of a job and everything that is added to it
+// until the job is finished
+func followTrace(pid string, jobId int, interval time.Duration) error {
+	printed := 0
+	for {
+		// get the status before the trace, so that the last trace is complete
+		job, _, err := gitlabClient.Jobs.GetJob(pid, jobId)
+		if err != nil {
+			return err
+		}
+		trace, _, err := gitlabClient.Jobs.GetTraceFile(pid, jobId)
+		if err != nil {
+			return err
+		}
+		log, err := ioutil.ReadAll(trace)
+		if err != nil {
+			return err
+		}
+		if len(log) < printed {
+			// the trace was erased or restarted, print it from the beginning
+			printed = 0
+		}
+		if _, err := os.Stdout.Write(log[printed:]); err != nil {
+			return err
+		}
+		printed = len(log)
+		if jobFinished(job.Status) {
+			return nil
+		}
+		sleep(interval)
+	}
+}
+
+func jobFinished(status string) bool {
+	switch status {
+	case "success", "failed", "canceled", "skipped", "manual":
+		return true
+	}
+	return false
+}
+
+// see https://docs.gitlab.com/ce/api/jobs.html#get-job-artifacts
+var jobsArtifactsCmd = &golabCommand{
+	Parent: jobsCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "artifacts",
+		Short: "Job artifacts",
+		Long:  `Download the artifacts of jobs`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/jobs.html#get-job-artifacts
+// see https://docs.gitlab.com/ce/api/jobs.html#download-the-artifacts-file
+type jobsArtifactsDownloadFlags struct {
+	Id           *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	JobId        *int    `flag_name:"job_id" short:"j" type:"integer" required:"no" description:"The ID of a job"`
+	RefName      *string `flag_name:"ref_name" short:"r" type:"string" required:"no" description:"The ref from a repository, used with --job instead of --job_id to download the artifacts of the latest successful job"`
+	Job          *string `flag_name:"job" short:"n" type:"string" required:"no" description:"The name of the job, used with --ref_name"`
+	ArtifactPath *string `flag_name:"artifact_path" short:"a" type:"string" required:"no" description:"Path of a single file inside the artifacts archive that should be extracted"`
+	File         *string `flag_name:"file" short:"f" type:"string" required:"no" description:"File to write to, '-' for stdout (default: artifacts.zip or the file name of --artifact_path)"`
+}
+
+var jobsArtifactsDownloadCmd = &golabCommand{
+	Parent: jobsArtifactsCmd.Cmd,
+	Flags:  &jobsArtifactsDownloadFlags{},
+	Cmd: &cobra.Command{
+		Use:   "download",
+		Short: "Download job artifacts",
+		Long: `Download the artifacts archive of a job, either by job ID or by ref and job name.
+
+If --artifact_path is given, only this file is extracted from the archive.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*jobsArtifactsDownloadFlags)
+		var archive io.Reader
+		var err error
+		if flags.JobId != nil {
+			archive, _, err = gitlabClient.Jobs.GetJobArtifacts(*flags.Id, *flags.JobId)
+		} else if flags.RefName != nil && flags.Job != nil {
+			archive, _, err = gitlabClient.Jobs.DownloadArtifactsFile(*flags.Id, *flags.RefName, *flags.Job)
+		} else {
+			return errors.New("either --job_id or --ref_name and --job have to be given")
+		}
+		if err != nil {
+			return err
+		}
+
+		file := "artifacts.zip"
+		if flags.ArtifactPath != nil {
+			file = path.Base(*flags.ArtifactPath)
+			if archive, err = extractArtifact(archive, *flags.ArtifactPath); err != nil {
+				return err
+			}
+		}
+		if flags.File != nil {
+			file = *flags.File
+		}
+		return writeArtifact(archive, file)
+	},
+}
+
+// extractArtifact returns the content of the file at artifactPath in the
+// given zip archive
+func extractArtifact(archive io.Reader, artifactPath string) (io.Reader, error) {
+	content, err := ioutil.ReadAll(archive)
+	if err != nil {
+		return nil, err
+	}
+	zipReader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range zipReader.File {
+		if f.Name == artifactPath {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			buf := new(bytes.Buffer)
+			_, err = io.Copy(buf, rc)
+			return buf, err
+		}
+	}
+	return nil, fmt.Errorf("artifact %s not found in artifacts archive", artifactPath)
+}
+
+func writeArtifact(content io.Reader, file string) error {
+	if file == "-" {
+		_, err := io.Copy(os.Stdout, content)
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, content)
+	return err
+}
+
+func init() {
+	AddDefaultColumns(gitlab.Job{}, "id", "name", "stage", "status", "ref")
+	jobsCmd.Init()
+	jobsListCmd.Init()
+	jobsGetCmd.Init()
+	jobsRetryCmd.Init()
+	jobsCancelCmd.Init()
+	jobsEraseCmd.Init()
+	jobsPlayCmd.Init()
+	jobsTraceCmd.Init()
+	jobsArtifactsCmd.Init()
+	jobsArtifactsDownloadCmd.Init()
+}
@@ -0,0 +1,114 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("jobs command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+		sleep = func(time.Duration) {}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		sleep = time.Sleep
+	})
+
+	Context("when the `trace` sub command is executed with `--follow`", func() {
+		It("prints every part of the log once until the job is finished", func() {
+			statuses := []string{"running", "running", "success"}
+			traces := []string{"line 1\n", "line 1\nline 2\n", "line 1\nline 2\nline 3\n"}
+			mux.HandleFunc("/api/v4/projects/1/jobs/7", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"id":7,"status":"%s"}`, statuses[0])
+				statuses = statuses[1:]
+			})
+			mux.HandleFunc("/api/v4/projects/1/jobs/7/trace", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, traces[0])
+				traces = traces[1:]
+			})
+			stdout, _, err := executeCommand(RootCmd, "jobs", "trace", "-i", "1", "-j", "7", "--follow")
+			Expect(err).To(BeNil())
+			Expect(stdout).To(Equal("line 1\nline 2\nline 3"))
+		})
+
+		It("keeps polling while the job is preparing", func() {
+			statuses := []string{"preparing", "running", "success"}
+			traces := []string{"", "line 1\n", "line 1\nline 2\n"}
+			mux.HandleFunc("/api/v4/projects/1/jobs/7", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"id":7,"status":"%s"}`, statuses[0])
+				statuses = statuses[1:]
+			})
+			mux.HandleFunc("/api/v4/projects/1/jobs/7/trace", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, traces[0])
+				traces = traces[1:]
+			})
+			stdout, _, err := executeCommand(RootCmd, "jobs", "trace", "-i", "1", "-j", "7", "--follow")
+			Expect(err).To(BeNil())
+			Expect(stdout).To(Equal("line 1\nline 2"))
+			Expect(statuses).To(BeEmpty())
+		})
+	})
+
+	Context("when the `artifacts download` sub command is executed with `--artifact_path`", func() {
+		It("extracts the file from the artifacts archive", func() {
+			archive := new(bytes.Buffer)
+			zipWriter := zip.NewWriter(archive)
+			f, _ := zipWriter.Create("build/report.txt")
+			f.Write([]byte("all green"))
+			zipWriter.Close()
+			mux.HandleFunc("/api/v4/projects/1/jobs/7/artifacts", func(w http.ResponseWriter, r *http.Request) {
+				w.Write(archive.Bytes())
+			})
+			target, _ := ioutil.TempFile("", "golab-artifact")
+			target.Close()
+			defer os.Remove(target.Name())
+
+			_, _, err := executeCommand(RootCmd, "jobs", "artifacts", "download", "-i", "1", "-j", "7", "-a", "build/report.txt", "-f", target.Name())
+			Expect(err).To(BeNil())
+			content, _ := ioutil.ReadFile(target.Name())
+			Expect(string(content)).To(Equal("all green"))
+		})
+	})
+})
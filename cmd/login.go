@@ -22,12 +22,8 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
-	"io/ioutil"
-	path2 "path"
-
 	"github.com/howeyc/gopass"
 	. "github.com/michaellihs/gogpat/gogpat"
 	"github.com/spf13/cobra"
@@ -49,7 +45,9 @@ var loginCmd = &golabCommand{
 	Cmd: &cobra.Command{
 		Use:   "login",
 		Short: "Login to Gitlab",
-		Long:  `Login to Gitlab using username and password`,
+		Long: `Login to Gitlab using username and password
+
+The generated token is written to the golab config file. Use --env to add or update a named environment without touching the other entries of the config file.`,
 	},
 	Run: func(cmd golabCommand) error {
 		flags := cmd.Flags.(*loginFlags)
@@ -72,7 +70,6 @@ var loginCmd = &golabCommand{
 		if err != nil {
 			return err
 		}
-		// TODO add path to write config to
 		err = writeGolabConf(*flags.Host, token)
 		if err != nil {
 			return err
@@ -162,13 +159,28 @@ var personalAccessTokenCmd = &golabCommand{
 	},
 }
 
+// writeGolabConf stores url and token in the golab config file. If an
+// environment is given with --env or $GOLAB_ENV, only this environment is
+// added or updated, otherwise the top-level url and token are written.
 func writeGolabConf(host string, token string) error {
-	conf := []byte(fmt.Sprintf("---\nurl: \"%s\"\ntoken: \"%s\"", host, token))
-	pwd, err := os.Getwd()
-	filename := path2.Join(pwd, ".golab.yml")
-	err = ioutil.WriteFile(filename, conf, 0600)
+	conf, err := currentGolabConfig()
+	if err != nil {
+		return err
+	}
+	if env := explicitEnvironment(); env != "" {
+		if err := conf.setEnvironment(env, host, token); err != nil {
+			return err
+		}
+		if _, hasUrl := conf.get("url"); !hasUrl && conf.currentEnvironment() == "" {
+			conf.set(currentEnvKey, env)
+		}
+	} else {
+		conf.set("url", host)
+		conf.set("token", token)
+	}
+	err = conf.write()
 	if err == nil {
-		fmt.Printf("** golab config written to %s\n", filename)
+		fmt.Printf("** golab config written to %s\n", conf.filename)
 	}
 	return err
 }
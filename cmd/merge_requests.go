@@ -626,6 +626,87 @@ var mergeRequestsGetTimeTrackingStatsCmd = &golabCommand{
 	},
 }
 
+type mergeRequestsCommentFlags struct {
+	Id              *string `flag_name:"id" short:"i" type:"integer/string" required:"no" description:"The ID or URL-encoded path of the project (default: project of the origin remote of the repository in the current directory)"`
+	MergeRequestIid *int    `flag_name:"merge_request_iid" short:"m" type:"integer" required:"no" description:"The IID of the merge request (default: the open merge request for the current branch)"`
+	Body            *string `flag_name:"body" short:"b" type:"string" required:"no" description:"The content of the comment"`
+	BodyFile        *string `flag_name:"body_file" short:"f" type:"string" required:"no" description:"File to read the content of the comment from, '-' reads from stdin"`
+}
+
+// listMergeRequestsForBranchOptions filters merge requests by source branch,
+// which is not supported by the vendored MergeRequestsService
+type listMergeRequestsForBranchOptions struct {
+	State        *string `url:"state,omitempty" json:"state,omitempty"`
+	SourceBranch *string `url:"source_branch,omitempty" json:"source_branch,omitempty"`
+}
+
+var mergeRequestsCommentCmd = &golabCommand{
+	Parent: mergeRequestsCmd,
+	Flags:  &mergeRequestsCommentFlags{},
+	Cmd: &cobra.Command{
+		Use:   "comment",
+		Short: "Comment on a merge request",
+		Long: `Adds a comment (note) to a merge request.
+
+Without --id and --merge_request_iid, the comment is added to the open merge request for the current branch of the repository in the current directory, e.g.
+
+    make test | golab mr comment --body_file -`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*mergeRequestsCommentFlags)
+		body, err := contentFromFlags(flags.Body, flags.BodyFile, "body", "body_file")
+		if err != nil {
+			return err
+		}
+		pid := ""
+		if flags.Id != nil {
+			pid = *flags.Id
+		} else if pid, err = currentProjectPath(); err != nil {
+			return err
+		}
+		iid := 0
+		if flags.MergeRequestIid != nil {
+			iid = *flags.MergeRequestIid
+		} else if iid, err = currentBranchMergeRequestIid(pid); err != nil {
+			return err
+		}
+		note, _, err := gitlabClient.Notes.CreateMergeRequestNote(pid, iid, &gitlab.CreateMergeRequestNoteOptions{Body: &body})
+		if err != nil {
+			return err
+		}
+		return OutputJson(note)
+	},
+}
+
+func currentProjectPath() (string, error) {
+	gh := GitHelper()
+	remotes, err := gh.GetRemotes()
+	if err != nil {
+		return "", err
+	}
+	remote, err := gh.GetRemoteUrl(remotes)
+	if err != nil {
+		return "", err
+	}
+	return gh.GetProjectPath(remote)
+}
+
+func currentBranchMergeRequestIid(pid string) (int, error) {
+	branch, err := GitHelper().GetCurrentBranch()
+	if err != nil {
+		return 0, err
+	}
+	opts := &listMergeRequestsForBranchOptions{State: gitlab.String("opened"), SourceBranch: &branch}
+	var mrs []*gitlab.MergeRequest
+	if _, err := gitlabRequest("GET", projectPath(pid, "/merge_requests"), opts, &mrs); err != nil {
+		return 0, err
+	}
+	if len(mrs) == 0 {
+		return 0, errors.New("there is no open merge request for branch " + branch)
+	}
+	return mrs[0].IID, nil
+}
+
 func init() {
 	mergeRequestsListCmd.Init()
 	mergeRequestsListForProjectCmd.Init()
@@ -648,5 +729,6 @@ func init() {
 	mergeRequestsAddSpentTimeCmd.Init()
 	mergeRequestsResetSpentTimeCmd.Init()
 	mergeRequestsGetTimeTrackingStatsCmd.Init()
+	mergeRequestsCommentCmd.Init()
 	RootCmd.AddCommand(mergeRequestsCmd)
 }
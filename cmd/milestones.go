@@ -0,0 +1,389 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// see https://docs.gitlab.com/ce/api/milestones.html
+var milestonesCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "milestones",
+		Aliases: []string{"milestone"},
+		Short:   "Manage project milestones",
+		Long:    `Show, create, edit, close and activate project milestones and summarize their progress`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/milestones.html#list-project-milestones
+type milestonesListFlags struct {
+	Id   *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IIDs []int   `flag_name:"iids" type:"Array[integer]" required:"no" description:"Return only the milestones having the given iids"`
+}
+
+var milestonesListCmd = &golabCommand{
+	Parent: milestonesCmd.Cmd,
+	Flags:  &milestonesListFlags{},
+	Opts:   &gitlab.ListMilestonesOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List project milestones",
+		Long:  `Returns a list of project milestones.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*milestonesListFlags)
+		opts := cmd.Opts.(*gitlab.ListMilestonesOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			return gitlabClient.Milestones.ListMilestones(*flags.Id, opts)
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/milestones.html#get-single-milestone
+type milestonesGetFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	MilestoneId *int    `flag_name:"milestone_id" short:"m" type:"integer" required:"yes" description:"The ID of the project's milestone"`
+}
+
+var milestonesGetCmd = &golabCommand{
+	Parent: milestonesCmd.Cmd,
+	Flags:  &milestonesGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get single milestone",
+		Long:  `Gets a single project milestone.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*milestonesGetFlags)
+		milestone, _, err := gitlabClient.Milestones.GetMilestone(*flags.Id, *flags.MilestoneId)
+		if err != nil {
+			return err
+		}
+		return OutputJson(milestone)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/milestones.html#create-new-milestone
+type milestonesCreateFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Title       *string `flag_name:"title" short:"t" type:"string" required:"yes" description:"The title of a milestone"`
+	Description *string `flag_name:"description" short:"d" type:"string" required:"no" description:"The description of the milestone"`
+	DueDate     *string `flag_name:"due_date" type:"string" required:"no" description:"The due date of the milestone (YYYY-MM-DD)"`
+	StartDate   *string `flag_name:"start_date" type:"string" required:"no" description:"The start date of the milestone (YYYY-MM-DD)"`
+}
+
+var milestonesCreateCmd = &golabCommand{
+	Parent: milestonesCmd.Cmd,
+	Flags:  &milestonesCreateFlags{},
+	Opts:   &gitlab.CreateMilestoneOptions{},
+	Cmd: &cobra.Command{
+		Use:   "create",
+		Short: "Create new milestone",
+		Long:  `Creates a new project milestone.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*milestonesCreateFlags)
+		opts := cmd.Opts.(*gitlab.CreateMilestoneOptions)
+		milestone, _, err := gitlabClient.Milestones.CreateMilestone(*flags.Id, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(milestone)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/milestones.html#edit-milestone
+type milestonesEditFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	MilestoneId *int    `flag_name:"milestone_id" short:"m" type:"integer" required:"yes" description:"The ID of the project's milestone"`
+	Title       *string `flag_name:"title" short:"t" type:"string" required:"no" description:"The title of a milestone"`
+	Description *string `flag_name:"description" short:"d" type:"string" required:"no" description:"The description of the milestone"`
+	DueDate     *string `flag_name:"due_date" type:"string" required:"no" description:"The due date of the milestone (YYYY-MM-DD)"`
+	StartDate   *string `flag_name:"start_date" type:"string" required:"no" description:"The start date of the milestone (YYYY-MM-DD)"`
+	StateEvent  *string `flag_name:"state_event" type:"string" required:"no" description:"The state event of the milestone (close or activate)"`
+}
+
+var milestonesEditCmd = &golabCommand{
+	Parent: milestonesCmd.Cmd,
+	Flags:  &milestonesEditFlags{},
+	Opts:   &gitlab.UpdateMilestoneOptions{},
+	Cmd: &cobra.Command{
+		Use:   "edit",
+		Short: "Edit milestone",
+		Long:  `Updates an existing project milestone.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*milestonesEditFlags)
+		opts := cmd.Opts.(*gitlab.UpdateMilestoneOptions)
+		milestone, _, err := gitlabClient.Milestones.UpdateMilestone(*flags.Id, *flags.MilestoneId, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(milestone)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/milestones.html#edit-milestone
+type milestonesCloseFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	MilestoneId *int    `flag_name:"milestone_id" short:"m" type:"integer" required:"yes" description:"The ID of the project's milestone"`
+}
+
+var milestonesCloseCmd = &golabCommand{
+	Parent: milestonesCmd.Cmd,
+	Flags:  &milestonesCloseFlags{},
+	Cmd: &cobra.Command{
+		Use:   "close",
+		Short: "Close milestone",
+		Long:  `Closes a milestone, same as 'edit --state_event close'.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*milestonesCloseFlags)
+		return updateMilestoneState(*flags.Id, *flags.MilestoneId, "close")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/milestones.html#edit-milestone
+type milestonesActivateFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	MilestoneId *int    `flag_name:"milestone_id" short:"m" type:"integer" required:"yes" description:"The ID of the project's milestone"`
+}
+
+var milestonesActivateCmd = &golabCommand{
+	Parent: milestonesCmd.Cmd,
+	Flags:  &milestonesActivateFlags{},
+	Cmd: &cobra.Command{
+		Use:   "activate",
+		Short: "Activate milestone",
+		Long:  `Activates a closed milestone, same as 'edit --state_event activate'.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*milestonesActivateFlags)
+		return updateMilestoneState(*flags.Id, *flags.MilestoneId, "activate")
+	},
+}
+
+func updateMilestoneState(pid string, milestoneId int, stateEvent string) error {
+	opts := &gitlab.UpdateMilestoneOptions{StateEvent: &stateEvent}
+	milestone, _, err := gitlabClient.Milestones.UpdateMilestone(pid, milestoneId, opts)
+	if err != nil {
+		return err
+	}
+	return OutputJson(milestone)
+}
+
+// see https://docs.gitlab.com/ce/api/milestones.html#get-all-issues-assigned-to-a-single-milestone
+type milestonesIssuesFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	MilestoneId *int    `flag_name:"milestone_id" short:"m" type:"integer" required:"yes" description:"The ID of the project's milestone"`
+}
+
+var milestonesIssuesCmd = &golabCommand{
+	Parent: milestonesCmd.Cmd,
+	Flags:  &milestonesIssuesFlags{},
+	Opts:   &gitlab.GetMilestoneIssuesOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "issues",
+		Short: "Get all issues assigned to a single milestone",
+		Long:  `Gets all issues assigned to a single project milestone.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*milestonesIssuesFlags)
+		opts := cmd.Opts.(*gitlab.GetMilestoneIssuesOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			return gitlabClient.Milestones.GetMilestoneIssues(*flags.Id, *flags.MilestoneId, opts)
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/milestones.html#get-all-merge-requests-assigned-to-a-single-milestone
+type milestonesMergeRequestsFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	MilestoneId *int    `flag_name:"milestone_id" short:"m" type:"integer" required:"yes" description:"The ID of the project's milestone"`
+}
+
+// getMilestoneMergeRequestsOptions represents the options of the milestone
+// merge requests API, which is not supported by the vendored MilestonesService
+type getMilestoneMergeRequestsOptions struct {
+	gitlab.ListOptions
+}
+
+var milestonesMergeRequestsCmd = &golabCommand{
+	Parent: milestonesCmd.Cmd,
+	Flags:  &milestonesMergeRequestsFlags{},
+	Opts:   &getMilestoneMergeRequestsOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "merge-requests",
+		Short: "Get all merge requests assigned to a single milestone",
+		Long:  `Gets all merge requests assigned to a single project milestone.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*milestonesMergeRequestsFlags)
+		opts := cmd.Opts.(*getMilestoneMergeRequestsOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			var mrs []*gitlab.MergeRequest
+			resp, err := gitlabRequest("GET", projectPath(*flags.Id, "/milestones/%d/merge_requests", *flags.MilestoneId), opts, &mrs)
+			return mrs, resp, err
+		})
+	},
+}
+
+type milestonesSummaryFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	MilestoneId *int    `flag_name:"milestone_id" short:"m" type:"integer" required:"yes" description:"The ID of the project's milestone"`
+}
+
+// milestoneSummary is the progress of a milestone, computed from its issues
+type milestoneSummary struct {
+	Id                  int     `json:"id"`
+	Title               string  `json:"title"`
+	State               string  `json:"state"`
+	DueDate             string  `json:"due_date"`
+	Issues              int     `json:"issues"`
+	OpenIssues          int     `json:"open_issues"`
+	ClosedIssues        int     `json:"closed_issues"`
+	Progress            float64 `json:"progress"`
+	TimeEstimate        int     `json:"time_estimate"`
+	TotalTimeSpent      int     `json:"total_time_spent"`
+	HumanTimeEstimate   string  `json:"human_time_estimate"`
+	HumanTotalTimeSpent string  `json:"human_total_time_spent"`
+}
+
+var milestonesSummaryCmd = &golabCommand{
+	Parent: milestonesCmd.Cmd,
+	Flags:  &milestonesSummaryFlags{},
+	Cmd: &cobra.Command{
+		Use:   "summary",
+		Short: "Summarize the progress of a milestone",
+		Long: `Counts the open and closed issues of a milestone and adds up their estimated and spent time.
+
+progress is the percentage of closed issues, times are given in seconds and in the human readable format of Gitlab (1w = 5d, 1d = 8h).`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*milestonesSummaryFlags)
+		milestone, _, err := gitlabClient.Milestones.GetMilestone(*flags.Id, *flags.MilestoneId)
+		if err != nil {
+			return err
+		}
+		var issues []*gitlab.Issue
+		opts := &gitlab.GetMilestoneIssuesOptions{}
+		err = forEachPage(&opts.ListOptions, func() (*gitlab.Response, error) {
+			page, resp, err := gitlabClient.Milestones.GetMilestoneIssues(*flags.Id, *flags.MilestoneId, opts)
+			issues = append(issues, page...)
+			return resp, err
+		})
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			// older Gitlab versions do not return time stats in issue lists
+			if issue.TimeStats == nil {
+				if issue.TimeStats, _, err = gitlabClient.Issues.GetTimeSpent(*flags.Id, issue.IID); err != nil {
+					return err
+				}
+			}
+		}
+		return OutputJson(summarizeMilestone(milestone, issues))
+	},
+}
+
+func summarizeMilestone(milestone *gitlab.Milestone, issues []*gitlab.Issue) milestoneSummary {
+	summary := milestoneSummary{
+		Id:      milestone.ID,
+		Title:   milestone.Title,
+		State:   milestone.State,
+		DueDate: milestone.DueDate,
+		Issues:  len(issues),
+	}
+	for _, issue := range issues {
+		if issue.State == "closed" {
+			summary.ClosedIssues++
+		} else {
+			summary.OpenIssues++
+		}
+		if issue.TimeStats != nil {
+			summary.TimeEstimate += issue.TimeStats.TimeEstimate
+			summary.TotalTimeSpent += issue.TimeStats.TotalTimeSpent
+		}
+	}
+	if summary.Issues > 0 {
+		summary.Progress = float64(summary.ClosedIssues*100) / float64(summary.Issues)
+	}
+	summary.HumanTimeEstimate = humanDuration(summary.TimeEstimate)
+	summary.HumanTotalTimeSpent = humanDuration(summary.TotalTimeSpent)
+	return summary
+}
+
+// humanDuration formats seconds like Gitlab's time tracking, where a week has
+// 5 days and a day has 8 hours
+func humanDuration(seconds int) string {
+	if seconds == 0 {
+		return "0m"
+	}
+	units := []struct {
+		suffix  string
+		seconds int
+	}{
+		{"w", 5 * 8 * 60 * 60},
+		{"d", 8 * 60 * 60},
+		{"h", 60 * 60},
+		{"m", 60},
+		{"s", 1},
+	}
+	var parts []string
+	for _, unit := range units {
+		if seconds >= unit.seconds {
+			parts = append(parts, fmt.Sprintf("%d%s", seconds/unit.seconds, unit.suffix))
+			seconds %= unit.seconds
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func init() {
+	milestonesCmd.Init()
+	milestonesListCmd.Init()
+	milestonesGetCmd.Init()
+	milestonesCreateCmd.Init()
+	milestonesEditCmd.Init()
+	milestonesCloseCmd.Init()
+	milestonesActivateCmd.Init()
+	milestonesIssuesCmd.Init()
+	milestonesMergeRequestsCmd.Init()
+	milestonesSummaryCmd.Init()
+	AddDefaultColumns(gitlab.Milestone{}, "id", "iid", "title", "state", "due_date")
+	AddDefaultColumns(milestoneSummary{}, "title", "state", "open_issues", "closed_issues", "progress", "human_time_estimate", "human_total_time_spent")
+}
@@ -0,0 +1,47 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("summarizeMilestone", func() {
+	It("adds up issue counts and time stats", func() {
+		milestone := &gitlab.Milestone{ID: 1, Title: "v1.0", State: "active"}
+		issues := []*gitlab.Issue{
+			{State: "closed", TimeStats: &gitlab.TimeStats{TimeEstimate: 3600, TotalTimeSpent: 5400}},
+			{State: "opened", TimeStats: &gitlab.TimeStats{TimeEstimate: 8 * 3600}},
+			{State: "opened"},
+			{State: "closed"},
+		}
+		summary := summarizeMilestone(milestone, issues)
+		Expect(summary.Issues).To(Equal(4))
+		Expect(summary.OpenIssues).To(Equal(2))
+		Expect(summary.ClosedIssues).To(Equal(2))
+		Expect(summary.Progress).To(Equal(50.0))
+		Expect(summary.TimeEstimate).To(Equal(9 * 3600))
+		Expect(summary.HumanTimeEstimate).To(Equal("1d 1h"))
+		Expect(summary.HumanTotalTimeSpent).To(Equal("1h 30m"))
+	})
+})
@@ -63,11 +63,9 @@ var namespacesListCmd = &golabCommand{
 	},
 	Run: func(cmd golabCommand) error {
 		opts := cmd.Opts.(*gitlab.ListNamespacesOptions)
-		ns, _, err := gitlabClient.Namespaces.ListNamespaces(opts)
-		if err != nil {
-			return err
-		}
-		return OutputJson(ns)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			return gitlabClient.Namespaces.ListNamespaces(opts)
+		})
 	},
 }
 
@@ -0,0 +1,287 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// see https://docs.gitlab.com/ce/api/notes.html
+var notesCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "notes",
+		Aliases: []string{"note"},
+		Short:   "Manage notes",
+		Long: `Show, create, edit and delete notes (comments) on issues, merge requests and snippets.
+
+Select the commented object with exactly one of --issue_iid, --merge_request_iid or --snippet_id.`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// noteable is the object notes are attached to
+type noteable struct {
+	resource string // path segment of the noteable in the API: issues, merge_requests or snippets
+	id       int
+}
+
+func selectNoteable(issueIid, mergeRequestIid, snippetId *int) (noteable, error) {
+	var selected []noteable
+	if issueIid != nil {
+		selected = append(selected, noteable{"issues", *issueIid})
+	}
+	if mergeRequestIid != nil {
+		selected = append(selected, noteable{"merge_requests", *mergeRequestIid})
+	}
+	if snippetId != nil {
+		selected = append(selected, noteable{"snippets", *snippetId})
+	}
+	if len(selected) != 1 {
+		return noteable{}, errors.New("exactly one of --issue_iid, --merge_request_iid or --snippet_id has to be given")
+	}
+	return selected[0], nil
+}
+
+// see https://docs.gitlab.com/ce/api/notes.html#list-project-issue-notes
+type notesListFlags struct {
+	Id              *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid        *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"no" description:"The IID of an issue"`
+	MergeRequestIid *int    `flag_name:"merge_request_iid" short:"m" type:"integer" required:"no" description:"The IID of a merge request"`
+	SnippetId       *int    `flag_name:"snippet_id" short:"s" type:"integer" required:"no" description:"The ID of a snippet"`
+	Sort            *string `flag_name:"sort" type:"string" required:"no" description:"Return notes sorted in asc or desc order. Default is desc"`
+	OrderBy         *string `flag_name:"order_by" type:"string" required:"no" description:"Return notes ordered by created_at or updated_at fields. Default is created_at"`
+}
+
+// listNotesOptions represents the options of the list notes APIs, which
+// are only partly supported by the vendored NotesService
+type listNotesOptions struct {
+	gitlab.ListOptions
+	Sort    *string `url:"sort,omitempty" json:"sort,omitempty"`
+	OrderBy *string `url:"order_by,omitempty" json:"order_by,omitempty"`
+}
+
+var notesListCmd = &golabCommand{
+	Parent: notesCmd.Cmd,
+	Flags:  &notesListFlags{},
+	Opts:   &listNotesOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List notes",
+		Long:  `Gets a list of all notes for a single issue, merge request or snippet.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*notesListFlags)
+		opts := cmd.Opts.(*listNotesOptions)
+		n, err := selectNoteable(flags.IssueIid, flags.MergeRequestIid, flags.SnippetId)
+		if err != nil {
+			return err
+		}
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			var notes []*gitlab.Note
+			resp, err := gitlabRequest("GET", projectPath(*flags.Id, "/%s/%d/notes", n.resource, n.id), opts, &notes)
+			return notes, resp, err
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/notes.html#get-single-issue-note
+type notesGetFlags struct {
+	Id              *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid        *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"no" description:"The IID of an issue"`
+	MergeRequestIid *int    `flag_name:"merge_request_iid" short:"m" type:"integer" required:"no" description:"The IID of a merge request"`
+	SnippetId       *int    `flag_name:"snippet_id" short:"s" type:"integer" required:"no" description:"The ID of a snippet"`
+	NoteId          *int    `flag_name:"note_id" type:"integer" required:"yes" description:"The ID of a note"`
+}
+
+var notesGetCmd = &golabCommand{
+	Parent: notesCmd.Cmd,
+	Flags:  &notesGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get single note",
+		Long:  `Returns a single note for a given issue, merge request or snippet.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*notesGetFlags)
+		n, err := selectNoteable(flags.IssueIid, flags.MergeRequestIid, flags.SnippetId)
+		if err != nil {
+			return err
+		}
+		var note *gitlab.Note
+		switch n.resource {
+		case "issues":
+			note, _, err = gitlabClient.Notes.GetIssueNote(*flags.Id, n.id, *flags.NoteId)
+		case "merge_requests":
+			note, _, err = gitlabClient.Notes.GetMergeRequestNote(*flags.Id, n.id, *flags.NoteId)
+		case "snippets":
+			note, _, err = gitlabClient.Notes.GetSnippetNote(*flags.Id, n.id, *flags.NoteId)
+		}
+		if err != nil {
+			return err
+		}
+		return OutputJson(note)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/notes.html#create-new-issue-note
+type notesCreateFlags struct {
+	Id              *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid        *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"no" description:"The IID of an issue"`
+	MergeRequestIid *int    `flag_name:"merge_request_iid" short:"m" type:"integer" required:"no" description:"The IID of a merge request"`
+	SnippetId       *int    `flag_name:"snippet_id" short:"s" type:"integer" required:"no" description:"The ID of a snippet"`
+	Body            *string `flag_name:"body" short:"b" type:"string" required:"no" description:"The content of a note"`
+	BodyFile        *string `flag_name:"body_file" short:"f" type:"string" required:"no" description:"File to read the content of the note from, '-' reads from stdin"`
+}
+
+var notesCreateCmd = &golabCommand{
+	Parent: notesCmd.Cmd,
+	Flags:  &notesCreateFlags{},
+	Cmd: &cobra.Command{
+		Use:   "create",
+		Short: "Create new note",
+		Long: `Creates a new note for a single issue, merge request or snippet.
+
+The content of the note is given with --body or read from a file with --body_file, use '--body_file -' to read it from stdin.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*notesCreateFlags)
+		n, err := selectNoteable(flags.IssueIid, flags.MergeRequestIid, flags.SnippetId)
+		if err != nil {
+			return err
+		}
+		body, err := contentFromFlags(flags.Body, flags.BodyFile, "body", "body_file")
+		if err != nil {
+			return err
+		}
+		var note *gitlab.Note
+		switch n.resource {
+		case "issues":
+			note, _, err = gitlabClient.Notes.CreateIssueNote(*flags.Id, n.id, &gitlab.CreateIssueNoteOptions{Body: &body})
+		case "merge_requests":
+			note, _, err = gitlabClient.Notes.CreateMergeRequestNote(*flags.Id, n.id, &gitlab.CreateMergeRequestNoteOptions{Body: &body})
+		case "snippets":
+			note, _, err = gitlabClient.Notes.CreateSnippetNote(*flags.Id, n.id, &gitlab.CreateSnippetNoteOptions{Body: &body})
+		}
+		if err != nil {
+			return err
+		}
+		return OutputJson(note)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/notes.html#modify-existing-issue-note
+type notesEditFlags struct {
+	Id              *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid        *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"no" description:"The IID of an issue"`
+	MergeRequestIid *int    `flag_name:"merge_request_iid" short:"m" type:"integer" required:"no" description:"The IID of a merge request"`
+	SnippetId       *int    `flag_name:"snippet_id" short:"s" type:"integer" required:"no" description:"The ID of a snippet"`
+	NoteId          *int    `flag_name:"note_id" type:"integer" required:"yes" description:"The ID of a note"`
+	Body            *string `flag_name:"body" short:"b" type:"string" required:"no" description:"The content of a note"`
+	BodyFile        *string `flag_name:"body_file" short:"f" type:"string" required:"no" description:"File to read the content of the note from, '-' reads from stdin"`
+}
+
+var notesEditCmd = &golabCommand{
+	Parent: notesCmd.Cmd,
+	Flags:  &notesEditFlags{},
+	Cmd: &cobra.Command{
+		Use:   "edit",
+		Short: "Modify existing note",
+		Long:  `Modify existing note of an issue, merge request or snippet.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*notesEditFlags)
+		n, err := selectNoteable(flags.IssueIid, flags.MergeRequestIid, flags.SnippetId)
+		if err != nil {
+			return err
+		}
+		body, err := contentFromFlags(flags.Body, flags.BodyFile, "body", "body_file")
+		if err != nil {
+			return err
+		}
+		var note *gitlab.Note
+		switch n.resource {
+		case "issues":
+			note, _, err = gitlabClient.Notes.UpdateIssueNote(*flags.Id, n.id, *flags.NoteId, &gitlab.UpdateIssueNoteOptions{Body: &body})
+		case "merge_requests":
+			note, _, err = gitlabClient.Notes.UpdateMergeRequestNote(*flags.Id, n.id, *flags.NoteId, &gitlab.UpdateMergeRequestNoteOptions{Body: &body})
+		case "snippets":
+			note, _, err = gitlabClient.Notes.UpdateSnippetNote(*flags.Id, n.id, *flags.NoteId, &gitlab.UpdateSnippetNoteOptions{Body: &body})
+		}
+		if err != nil {
+			return err
+		}
+		return OutputJson(note)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/notes.html#delete-an-issue-note
+type notesDeleteFlags struct {
+	Id              *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	IssueIid        *int    `flag_name:"issue_iid" short:"n" type:"integer" required:"no" description:"The IID of an issue"`
+	MergeRequestIid *int    `flag_name:"merge_request_iid" short:"m" type:"integer" required:"no" description:"The IID of a merge request"`
+	SnippetId       *int    `flag_name:"snippet_id" short:"s" type:"integer" required:"no" description:"The ID of a snippet"`
+	NoteId          *int    `flag_name:"note_id" type:"integer" required:"yes" description:"The ID of a note"`
+}
+
+var notesDeleteCmd = &golabCommand{
+	Parent: notesCmd.Cmd,
+	Flags:  &notesDeleteFlags{},
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a note",
+		Long:  `Deletes an existing note of an issue, merge request or snippet.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*notesDeleteFlags)
+		n, err := selectNoteable(flags.IssueIid, flags.MergeRequestIid, flags.SnippetId)
+		if err != nil {
+			return err
+		}
+		switch n.resource {
+		case "issues":
+			_, err = gitlabClient.Notes.DeleteIssueNote(*flags.Id, n.id, *flags.NoteId)
+		case "merge_requests":
+			_, err = gitlabClient.Notes.DeleteMergeRequestNote(*flags.Id, n.id, *flags.NoteId)
+		case "snippets":
+			_, err = gitlabClient.Notes.DeleteSnippetNote(*flags.Id, n.id, *flags.NoteId)
+		}
+		return err
+	},
+}
+
+func init() {
+	AddDefaultColumns(gitlab.Note{}, "id", "author.username", "created_at", "body")
+	notesCmd.Init()
+	notesListCmd.Init()
+	notesGetCmd.Init()
+	notesCreateCmd.Init()
+	notesEditCmd.Init()
+	notesDeleteCmd.Init()
+}
@@ -0,0 +1,82 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("notes command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when the `create` sub command is executed with `--body_file -`", func() {
+		It("posts the note read from stdin to the merge request", func() {
+			body := ""
+			mux.HandleFunc("/api/v4/projects/1/merge_requests/5/notes", func(w http.ResponseWriter, r *http.Request) {
+				bodyBytes, _ := ioutil.ReadAll(r.Body)
+				body = string(bodyBytes)
+				fmt.Fprint(w, `{"id":9}`)
+			})
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			w.WriteString("42 tests passed")
+			w.Close()
+			os.Stdin = r
+
+			_, _, err := executeCommand(RootCmd, "notes", "create", "-i", "1", "-m", "5", "--body_file", "-")
+			Expect(err).To(BeNil())
+			Expect(body).To(Equal(`{"body":"42 tests passed"}`))
+		})
+	})
+
+	Context("when no or more than one noteable is given", func() {
+		It("should exit with error", func() {
+			_, _, err := executeCommand(RootCmd, "notes", "ls", "-i", "1", "-m", "5", "-n", "3")
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal("exactly one of --issue_iid, --merge_request_iid or --snippet_id has to be given"))
+		})
+	})
+})
@@ -0,0 +1,288 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+	"gopkg.in/yaml.v2"
+)
+
+var notificationLevels = []string{"disabled", "participating", "watch", "global", "mention", "custom"}
+
+// see https://docs.gitlab.com/ce/api/notification_settings.html
+var notificationsCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "notifications",
+		Aliases: []string{"notification"},
+		Short:   "Manage notification settings",
+		Long:    `Get and change the global notification settings of the authenticated user or the settings for a group (--group) or project (--project)`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/notification_settings.html#global-notification-settings
+type notificationsGetFlags struct {
+	Group   *string `flag_name:"group" short:"g" type:"integer/string" required:"no" description:"The ID or URL-encoded path of a group to get the settings for"`
+	Project *string `flag_name:"project" short:"p" type:"integer/string" required:"no" description:"The ID or URL-encoded path of a project to get the settings for"`
+}
+
+var notificationsGetCmd = &golabCommand{
+	Parent: notificationsCmd.Cmd,
+	Flags:  &notificationsGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get notification settings",
+		Long:  `Get the global notification settings or the settings of a group or project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*notificationsGetFlags)
+		var settings *gitlab.NotificationSettings
+		var err error
+		switch {
+		case flags.Group != nil && flags.Project != nil:
+			return errors.New("only one of --group or --project can be given")
+		case flags.Group != nil:
+			settings, _, err = gitlabClient.NotificationSettings.GetSettingsForGroup(*flags.Group)
+		case flags.Project != nil:
+			settings, _, err = gitlabClient.NotificationSettings.GetSettingsForProject(*flags.Project)
+		default:
+			settings, _, err = gitlabClient.NotificationSettings.GetGlobalSettings()
+		}
+		if err != nil {
+			return err
+		}
+		return OutputJson(settings)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/notification_settings.html#update-global-notification-settings
+type notificationsSetFlags struct {
+	Group                *string `flag_name:"group" short:"g" type:"integer/string" required:"no" description:"The ID or URL-encoded path of a group to change the settings for"`
+	Project              *string `flag_name:"project" short:"p" type:"integer/string" required:"no" description:"The ID or URL-encoded path of a project to change the settings for"`
+	Level                *string `flag_name:"level" short:"l" type:"string" required:"no" description:"The notification level: disabled, participating, watch, global (not for global settings), mention or custom"`
+	NotificationEmail    *string `flag_name:"notification_email" type:"string" required:"no" description:"The email address to send notifications to (only global settings)"`
+	CloseIssue           *bool   `flag_name:"close_issue" type:"boolean" required:"no" description:"Enable/disable this notification"`
+	CloseMergeRequest    *bool   `flag_name:"close_merge_request" type:"boolean" required:"no" description:"Enable/disable this notification"`
+	FailedPipeline       *bool   `flag_name:"failed_pipeline" type:"boolean" required:"no" description:"Enable/disable this notification"`
+	MergeMergeRequest    *bool   `flag_name:"merge_merge_request" type:"boolean" required:"no" description:"Enable/disable this notification"`
+	NewIssue             *bool   `flag_name:"new_issue" type:"boolean" required:"no" description:"Enable/disable this notification"`
+	NewMergeRequest      *bool   `flag_name:"new_merge_request" type:"boolean" required:"no" description:"Enable/disable this notification"`
+	NewNote              *bool   `flag_name:"new_note" type:"boolean" required:"no" description:"Enable/disable this notification"`
+	ReassignIssue        *bool   `flag_name:"reassign_issue" type:"boolean" required:"no" description:"Enable/disable this notification"`
+	ReassignMergeRequest *bool   `flag_name:"reassign_merge_request" type:"boolean" required:"no" description:"Enable/disable this notification"`
+	ReopenIssue          *bool   `flag_name:"reopen_issue" type:"boolean" required:"no" description:"Enable/disable this notification"`
+	ReopenMergeRequest   *bool   `flag_name:"reopen_merge_request" type:"boolean" required:"no" description:"Enable/disable this notification"`
+	SuccessPipeline      *bool   `flag_name:"success_pipeline" type:"boolean" required:"no" description:"Enable/disable this notification"`
+}
+
+var notificationsSetCmd = &golabCommand{
+	Parent: notificationsCmd.Cmd,
+	Flags:  &notificationsSetFlags{},
+	Opts:   &gitlab.NotificationSettingsOptions{},
+	Cmd: &cobra.Command{
+		Use:   "set",
+		Short: "Update notification settings",
+		Long: `Update the global notification settings or the settings of a group or project.
+
+The individual events are only used with the custom level, e.g.
+
+    golab notifications set -p my-group/my-project -l custom --failed_pipeline --new_merge_request`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*notificationsSetFlags)
+		opts := cmd.Opts.(*gitlab.NotificationSettingsOptions)
+		if flags.Group != nil && flags.Project != nil {
+			return errors.New("only one of --group or --project can be given")
+		}
+		if flags.Level != nil {
+			level, err := notificationLevel(*flags.Level)
+			if err != nil {
+				return err
+			}
+			opts.Level = level
+		}
+		settings, err := updateNotificationSettings(flags.Group, flags.Project, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(settings)
+	},
+}
+
+type notificationsApplyFlags struct {
+	File *string `flag_name:"file" short:"f" type:"string" required:"yes" description:"YAML file with the notification profile, '-' reads from stdin"`
+}
+
+// notificationProfile is the content of the file read by 'notifications apply'
+type notificationProfile struct {
+	notificationSetting `yaml:",inline"`
+	Groups              []string             `yaml:"groups"`
+	Projects            []string             `yaml:"projects"`
+	Global              *notificationSetting `yaml:"global"`
+}
+
+type notificationSetting struct {
+	Level             string          `yaml:"level"`
+	NotificationEmail string          `yaml:"notification_email"`
+	Events            map[string]bool `yaml:"events"`
+}
+
+var notificationsApplyCmd = &golabCommand{
+	Parent: notificationsCmd.Cmd,
+	Flags:  &notificationsApplyFlags{},
+	Cmd: &cobra.Command{
+		Use:   "apply",
+		Short: "Apply a notification profile to groups and projects",
+		Long: `Apply the notification settings of a profile to all groups and projects listed in the profile and, optionally, to the global settings.
+
+Example profile:
+
+    level: custom
+    events:
+      failed_pipeline: true
+      new_merge_request: true
+    groups:
+      - my-group
+    projects:
+      - other-group/my-project
+    global:
+      level: participating`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*notificationsApplyFlags)
+		content, err := readFileOrStdin(*flags.File)
+		if err != nil {
+			return err
+		}
+		profile := &notificationProfile{}
+		if err := yaml.Unmarshal([]byte(content), profile); err != nil {
+			return err
+		}
+		return applyNotificationProfile(profile)
+	},
+}
+
+// applyNotificationProfile updates the settings of all groups and projects
+// and the global settings given in the profile
+func applyNotificationProfile(profile *notificationProfile) error {
+	if len(profile.Groups)+len(profile.Projects) > 0 {
+		opts, err := profile.notificationSetting.options()
+		if err != nil {
+			return err
+		}
+		for _, group := range profile.Groups {
+			if _, err := updateNotificationSettings(&group, nil, opts); err != nil {
+				return err
+			}
+			fmt.Println("updated group " + group)
+		}
+		for _, project := range profile.Projects {
+			if _, err := updateNotificationSettings(nil, &project, opts); err != nil {
+				return err
+			}
+			fmt.Println("updated project " + project)
+		}
+	}
+	if profile.Global != nil {
+		opts, err := profile.Global.options()
+		if err != nil {
+			return err
+		}
+		if _, err := updateNotificationSettings(nil, nil, opts); err != nil {
+			return err
+		}
+		fmt.Println("updated global settings")
+	}
+	return nil
+}
+
+// options converts the setting into the options of the API, events are
+// matched by the names of the options
+func (s notificationSetting) options() (*gitlab.NotificationSettingsOptions, error) {
+	generic := map[string]interface{}{}
+	for event, enabled := range s.Events {
+		generic[event] = enabled
+	}
+	if s.NotificationEmail != "" {
+		generic["notification_email"] = s.NotificationEmail
+	}
+	encoded, err := json.Marshal(generic)
+	if err != nil {
+		return nil, err
+	}
+	opts := &gitlab.NotificationSettingsOptions{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(opts); err != nil {
+		return nil, fmt.Errorf("invalid notification events: %s", err)
+	}
+	if s.Level != "" {
+		if opts.Level, err = notificationLevel(s.Level); err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// updateNotificationSettings updates the settings of a group or project or,
+// if neither is given, the global settings
+func updateNotificationSettings(group *string, project *string, opts *gitlab.NotificationSettingsOptions) (*gitlab.NotificationSettings, error) {
+	var settings *gitlab.NotificationSettings
+	var err error
+	switch {
+	case group != nil:
+		settings, _, err = gitlabClient.NotificationSettings.UpdateSettingsForGroup(*group, opts)
+	case project != nil:
+		settings, _, err = gitlabClient.NotificationSettings.UpdateSettingsForProject(*project, opts)
+	default:
+		settings, _, err = gitlabClient.NotificationSettings.UpdateGlobalSettings(opts)
+	}
+	return settings, err
+}
+
+func notificationLevel(level string) (*gitlab.NotificationLevelValue, error) {
+	for i, name := range notificationLevels {
+		if name == level {
+			return gitlab.NotificationLevel(gitlab.NotificationLevelValue(i)), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown notification level %s, use one of %s", level, strings.Join(notificationLevels, ", "))
+}
+
+func init() {
+	notificationsCmd.Init()
+	notificationsGetCmd.Init()
+	notificationsSetCmd.Init()
+	notificationsApplyCmd.Init()
+	AddDefaultColumns(gitlab.NotificationSettings{}, "level", "notification_email")
+}
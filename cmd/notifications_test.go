@@ -0,0 +1,91 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("notifications command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `apply` sub command is executed", func() {
+		It("applies the profile to all groups and projects and the global settings", func() {
+			bodies := map[string]map[string]interface{}{}
+			handler := func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal("PUT"))
+				var body map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&body)
+				bodies[r.URL.Path] = body
+				fmt.Fprint(w, `{}`)
+			}
+			mux.HandleFunc("/api/v4/groups/my-group/notification_settings", handler)
+			mux.HandleFunc("/api/v4/projects/42/notification_settings", handler)
+			mux.HandleFunc("/api/v4/notification_settings", handler)
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			w.WriteString("level: custom\nevents:\n  failed_pipeline: true\n  new_note: false\ngroups: [my-group]\nprojects: [42]\nglobal:\n  level: participating\n")
+			w.Close()
+			os.Stdin = r
+
+			out, _, err := executeCommand(RootCmd, "notifications", "apply", "-f", "-")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("updated group my-group\nupdated project 42\nupdated global settings"))
+			custom := map[string]interface{}{"level": "custom", "failed_pipeline": true, "new_note": false}
+			Expect(bodies["/api/v4/groups/my-group/notification_settings"]).To(Equal(custom))
+			Expect(bodies["/api/v4/projects/42/notification_settings"]).To(Equal(custom))
+			Expect(bodies["/api/v4/notification_settings"]).To(Equal(map[string]interface{}{"level": "participating"}))
+		})
+	})
+})
+
+var _ = Describe("notificationSetting", func() {
+	It("rejects unknown events and levels", func() {
+		_, err := notificationSetting{Events: map[string]bool{"new_pipeline": true}}.options()
+		Expect(err).NotTo(BeNil())
+		_, err = notificationSetting{Level: "loud"}.options()
+		Expect(err.Error()).To(Equal("unknown notification level loud, use one of disabled, participating, watch, global, mention, custom"))
+	})
+})
@@ -0,0 +1,119 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"reflect"
+	"strconv"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// maxPerPage is the maximum number of results Gitlab returns per page
+const maxPerPage = 100
+
+// pagedRequest fetches the page of a list that is currently set in the
+// ListOptions of a command's opts
+type pagedRequest func() (interface{}, *gitlab.Response, error)
+
+// outputPaged prints the result of a paged request. If --all or --limit are
+// given, all pages are fetched and printed as they arrive.
+func (c golabCommand) outputPaged(request pagedRequest) error {
+	all, err := c.Cmd.Flags().GetBool("all")
+	if err != nil {
+		return err
+	}
+	limit, err := c.Cmd.Flags().GetInt("limit")
+	if err != nil {
+		return err
+	}
+	if !all && limit <= 0 {
+		result, _, err := request()
+		if err != nil {
+			return err
+		}
+		return OutputJson(result)
+	}
+
+	stream := NewOutputStream()
+	count := 0
+	err = forEachPage(listOptions(c.Opts), func() (*gitlab.Response, error) {
+		result, resp, err := request()
+		if err != nil {
+			return nil, err
+		}
+		items := reflect.ValueOf(result)
+		if limit > 0 && count+items.Len() >= limit {
+			resp = nil // stop paging, we have enough results
+			items = items.Slice(0, limit-count)
+		}
+		count += items.Len()
+		return resp, stream.Write(items.Interface())
+	})
+	if err != nil {
+		return err
+	}
+	return stream.Close()
+}
+
+// forEachPage calls fetch for every page of a list, starting at the page set
+// in opts. Paging stops at the last page or if fetch returns no response.
+func forEachPage(opts *gitlab.ListOptions, fetch func() (*gitlab.Response, error)) error {
+	if opts.Page == 0 {
+		opts.Page = 1
+	}
+	if opts.PerPage == 0 {
+		opts.PerPage = maxPerPage
+	}
+	for {
+		resp, err := fetch()
+		if err != nil {
+			return err
+		}
+		next := nextPage(resp)
+		if next == 0 {
+			return nil
+		}
+		opts.Page = next
+	}
+}
+
+// nextPage returns the next page given in the Link header or, if there is
+// none, in the X-Next-Page header. 0 means there is no next page.
+func nextPage(resp *gitlab.Response) int {
+	if resp == nil {
+		return 0
+	}
+	if resp.NextPage != 0 {
+		return resp.NextPage
+	}
+	next, err := strconv.Atoi(resp.Header.Get("X-Next-Page"))
+	if err != nil {
+		return 0
+	}
+	return next
+}
+
+func listOptions(opts interface{}) *gitlab.ListOptions {
+	return reflect.ValueOf(opts).Elem().FieldByName("ListOptions").Addr().Interface().(*gitlab.ListOptions)
+}
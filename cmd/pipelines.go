@@ -0,0 +1,294 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// see https://docs.gitlab.com/ce/api/pipelines.html
+var pipelinesCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "pipelines",
+		Aliases: []string{"pipeline"},
+		Short:   "Manage pipelines",
+		Long:    `List, create, retry, cancel, delete and watch the CI pipelines of a project`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipelines.html#list-project-pipelines
+type pipelinesListFlags struct {
+	Id         *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Scope      *string `flag_name:"scope" short:"s" type:"string" required:"no" description:"The scope of pipelines, one of: running, pending, finished, branches, tags"`
+	Status     *string `flag_name:"status" type:"string" required:"no" description:"The status of pipelines, one of: running, pending, success, failed, canceled, skipped"`
+	Ref        *string `flag_name:"ref" short:"r" type:"string" required:"no" description:"The ref of pipelines"`
+	Sha        *string `flag_name:"sha" type:"string" required:"no" description:"The sha of pipelines"`
+	YamlErrors *bool   `flag_name:"yaml_errors" type:"boolean" required:"no" description:"Returns pipelines with invalid configurations"`
+	Name       *string `flag_name:"name" short:"n" type:"string" required:"no" description:"The name of the user who triggered pipelines"`
+	Username   *string `flag_name:"username" short:"u" type:"string" required:"no" description:"The username of the user who triggered pipelines"`
+	OrderBy    *string `flag_name:"order_by" type:"string" required:"no" description:"Order pipelines by id, status, ref, or user_id (default: id)"`
+	Sort       *string `flag_name:"sort" type:"string" required:"no" description:"Sort pipelines in asc or desc order (default: desc)"`
+}
+
+// listPipelinesOptions contains the filters of the list project pipelines API
+// that are not supported by the vendored PipelinesService
+type listPipelinesOptions struct {
+	gitlab.ListOptions
+	Scope      *string `url:"scope,omitempty" json:"scope,omitempty"`
+	Status     *string `url:"status,omitempty" json:"status,omitempty"`
+	Ref        *string `url:"ref,omitempty" json:"ref,omitempty"`
+	Sha        *string `url:"sha,omitempty" json:"sha,omitempty"`
+	YamlErrors *bool   `url:"yaml_errors,omitempty" json:"yaml_errors,omitempty"`
+	Name       *string `url:"name,omitempty" json:"name,omitempty"`
+	Username   *string `url:"username,omitempty" json:"username,omitempty"`
+	OrderBy    *string `url:"order_by,omitempty" json:"order_by,omitempty"`
+	Sort       *string `url:"sort,omitempty" json:"sort,omitempty"`
+}
+
+var pipelinesListCmd = &golabCommand{
+	Parent: pipelinesCmd.Cmd,
+	Flags:  &pipelinesListFlags{},
+	Opts:   &listPipelinesOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List project pipelines",
+		Long:    `List pipelines in a project`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*pipelinesListFlags)
+		opts := cmd.Opts.(*listPipelinesOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			var pipelines []*gitlab.Pipeline
+			resp, err := gitlabRequest("GET", projectPath(*flags.Id, "/pipelines"), opts, &pipelines)
+			return pipelines, resp, err
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipelines.html#get-a-single-pipeline
+type pipelinesGetFlags struct {
+	Id         *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	PipelineId *int    `flag_name:"pipeline_id" short:"p" type:"integer" required:"yes" description:"The ID of a pipeline"`
+}
+
+var pipelinesGetCmd = &golabCommand{
+	Parent: pipelinesCmd.Cmd,
+	Flags:  &pipelinesGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get a single pipeline",
+		Long:  `Get one pipeline of a project`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*pipelinesGetFlags)
+		pipeline, _, err := gitlabClient.Pipelines.GetPipeline(*flags.Id, *flags.PipelineId)
+		if err != nil {
+			return err
+		}
+		return OutputJson(pipeline)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipelines.html#create-a-new-pipeline
+type pipelinesCreateFlags struct {
+	Id  *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Ref *string `flag_name:"ref" short:"r" type:"string" required:"yes" description:"Reference to commit"`
+}
+
+var pipelinesCreateCmd = &golabCommand{
+	Parent: pipelinesCmd.Cmd,
+	Flags:  &pipelinesCreateFlags{},
+	Opts:   &gitlab.CreatePipelineOptions{},
+	Cmd: &cobra.Command{
+		Use:   "create",
+		Short: "Create a new pipeline",
+		Long:  `Create a new pipeline for the given ref`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*pipelinesCreateFlags)
+		opts := cmd.Opts.(*gitlab.CreatePipelineOptions)
+		pipeline, _, err := gitlabClient.Pipelines.CreatePipeline(*flags.Id, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(pipeline)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipelines.html#retry-failed-jobs-in-a-pipeline
+type pipelinesRetryFlags struct {
+	Id         *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	PipelineId *int    `flag_name:"pipeline_id" short:"p" type:"integer" required:"yes" description:"The ID of a pipeline"`
+}
+
+var pipelinesRetryCmd = &golabCommand{
+	Parent: pipelinesCmd.Cmd,
+	Flags:  &pipelinesRetryFlags{},
+	Cmd: &cobra.Command{
+		Use:   "retry",
+		Short: "Retry jobs in a pipeline",
+		Long:  `Retry the failed jobs of a pipeline`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*pipelinesRetryFlags)
+		pipeline, _, err := gitlabClient.Pipelines.RetryPipelineBuild(*flags.Id, *flags.PipelineId)
+		if err != nil {
+			return err
+		}
+		return OutputJson(pipeline)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipelines.html#cancel-a-pipelines-jobs
+type pipelinesCancelFlags struct {
+	Id         *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	PipelineId *int    `flag_name:"pipeline_id" short:"p" type:"integer" required:"yes" description:"The ID of a pipeline"`
+}
+
+var pipelinesCancelCmd = &golabCommand{
+	Parent: pipelinesCmd.Cmd,
+	Flags:  &pipelinesCancelFlags{},
+	Cmd: &cobra.Command{
+		Use:   "cancel",
+		Short: "Cancel a pipeline's jobs",
+		Long:  `Cancel the running and pending jobs of a pipeline`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*pipelinesCancelFlags)
+		pipeline, _, err := gitlabClient.Pipelines.CancelPipelineBuild(*flags.Id, *flags.PipelineId)
+		if err != nil {
+			return err
+		}
+		return OutputJson(pipeline)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipelines.html#delete-a-pipeline
+type pipelinesDeleteFlags struct {
+	Id         *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	PipelineId *int    `flag_name:"pipeline_id" short:"p" type:"integer" required:"yes" description:"The ID of a pipeline"`
+}
+
+var pipelinesDeleteCmd = &golabCommand{
+	Parent: pipelinesCmd.Cmd,
+	Flags:  &pipelinesDeleteFlags{},
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a pipeline",
+		Long:  `Delete a pipeline of a project. You must be an owner of the project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*pipelinesDeleteFlags)
+		_, err := gitlabRequest("DELETE", projectPath(*flags.Id, "/pipelines/%d", *flags.PipelineId), nil, nil)
+		return err
+	},
+}
+
+type pipelinesWatchFlags struct {
+	Id         *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	PipelineId *int    `flag_name:"pipeline_id" short:"p" type:"integer" required:"yes" description:"The ID of a pipeline"`
+	Interval   *int    `flag_name:"interval" type:"integer" required:"no" description:"Seconds to wait between two status requests (default: 5)"`
+	Timeout    *int    `flag_name:"timeout" short:"t" type:"integer" required:"no" description:"Seconds after which watching is aborted with an error (default: no timeout)"`
+}
+
+var pipelinesWatchCmd = &golabCommand{
+	Parent: pipelinesCmd.Cmd,
+	Flags:  &pipelinesWatchFlags{},
+	Cmd: &cobra.Command{
+		Use:   "watch",
+		Short: "Watch a pipeline until it finishes",
+		Long: `Poll the status of a pipeline until it finishes and print every status change.
+
+Exits with an error if the pipeline failed or was canceled or if it is blocked by a manual job (status manual), so it can be used to gate scripts on the result of a pipeline:
+
+    golab pipelines watch -i my-group/my-project -p 42 && ./release.sh`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*pipelinesWatchFlags)
+		interval := 5 * time.Second
+		if flags.Interval != nil && *flags.Interval > 0 {
+			interval = time.Duration(*flags.Interval) * time.Second
+		}
+		var timeout time.Duration
+		if flags.Timeout != nil {
+			timeout = time.Duration(*flags.Timeout) * time.Second
+		}
+		return watchPipeline(*flags.Id, *flags.PipelineId, interval, timeout)
+	},
+}
+
+// sleep is replaced in tests to not wait between polls
+var sleep = time.Sleep
+
+// watchPipeline polls a pipeline until it reaches a final status. A timeout of
+// 0 means to wait forever.
+func watchPipeline(pid string, pipelineId int, interval time.Duration, timeout time.Duration) error {
+	waited := time.Duration(0)
+	status := ""
+	for {
+		pipeline, _, err := gitlabClient.Pipelines.GetPipeline(pid, pipelineId)
+		if err != nil {
+			return err
+		}
+		if pipeline.Status != status {
+			status = pipeline.Status
+			fmt.Printf("%s pipeline #%d %s\n", time.Now().Format(time.RFC3339), pipeline.ID, status)
+		}
+		switch status {
+		case "success", "skipped":
+			return nil
+		case "manual":
+			return fmt.Errorf("pipeline #%d is waiting for a manual action", pipeline.ID)
+		case "failed", "canceled":
+			return fmt.Errorf("pipeline #%d finished with status %s", pipeline.ID, status)
+		}
+		if timeout > 0 && waited >= timeout {
+			return fmt.Errorf("pipeline #%d did not finish within %s", pipeline.ID, timeout)
+		}
+		sleep(interval)
+		waited += interval
+	}
+}
+
+func init() {
+	AddDefaultColumns(gitlab.Pipeline{}, "id", "status", "ref", "sha")
+	pipelinesCmd.Init()
+	pipelinesListCmd.Init()
+	pipelinesGetCmd.Init()
+	pipelinesCreateCmd.Init()
+	pipelinesRetryCmd.Init()
+	pipelinesCancelCmd.Init()
+	pipelinesDeleteCmd.Init()
+	pipelinesWatchCmd.Init()
+}
@@ -0,0 +1,103 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("pipelines command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+		sleep = func(time.Duration) {}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		sleep = time.Sleep
+	})
+
+	// serveStatuses lets the pipeline API return the given statuses one after another
+	serveStatuses := func(statuses ...string) {
+		mux.HandleFunc("/api/v4/projects/1/pipelines/42", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"id":42,"status":"%s"}`, statuses[0])
+			if len(statuses) > 1 {
+				statuses = statuses[1:]
+			}
+		})
+	}
+
+	Context("when the `watch` sub command is executed", func() {
+		It("prints status changes and succeeds if the pipeline succeeds", func() {
+			serveStatuses("pending", "running", "running", "success")
+			stdout, _, err := executeCommand(RootCmd, "pipelines", "watch", "-i", "1", "-p", "42")
+			Expect(err).To(BeNil())
+			Expect(stdout).To(ContainSubstring("pipeline #42 pending"))
+			Expect(stdout).To(ContainSubstring("pipeline #42 running"))
+			Expect(stdout).To(ContainSubstring("pipeline #42 success"))
+		})
+
+		It("fails if the pipeline fails", func() {
+			serveStatuses("running", "failed")
+			_, _, err := executeCommand(RootCmd, "pipelines", "watch", "-i", "1", "-p", "42")
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal("pipeline #42 finished with status failed"))
+		})
+
+		It("fails if the pipeline waits for a manual action", func() {
+			serveStatuses("running", "manual")
+			_, _, err := executeCommand(RootCmd, "pipelines", "watch", "-i", "1", "-p", "42")
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal("pipeline #42 is waiting for a manual action"))
+		})
+	})
+
+	Context("when the `ls` sub command is executed", func() {
+		It("passes the filters as query parameters", func() {
+			query := ""
+			mux.HandleFunc("/api/v4/projects/1/pipelines", func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.RawQuery
+				fmt.Fprint(w, `[]`)
+			})
+			_, _, err := executeCommand(RootCmd, "pipelines", "ls", "-i", "1", "--ref", "master", "--status", "failed")
+			Expect(err).To(BeNil())
+			Expect(query).To(ContainSubstring("ref=master"))
+			Expect(query).To(ContainSubstring("status=failed"))
+		})
+	})
+})
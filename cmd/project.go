@@ -75,11 +75,9 @@ var projectsListCmd = &golabCommand{
 	},
 	Run: func(cmd golabCommand) error {
 		opts := cmd.Opts.(*gitlab.ListProjectsOptions)
-		projects, _, err := gitlabClient.Projects.ListProjects(opts)
-		if err != nil {
-			return err
-		}
-		return OutputJson(projects)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			return gitlabClient.Projects.ListProjects(opts)
+		})
 	},
 }
 
@@ -0,0 +1,324 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// projectMember extends the vendored ProjectMember with the expiry date of
+// the membership
+type projectMember struct {
+	ID          int                     `json:"id"`
+	Username    string                  `json:"username"`
+	Email       string                  `json:"email"`
+	Name        string                  `json:"name"`
+	State       string                  `json:"state"`
+	CreatedAt   *time.Time              `json:"created_at"`
+	AccessLevel gitlab.AccessLevelValue `json:"access_level"`
+	ExpiresAt   *gitlab.ISOTime         `json:"expires_at"`
+}
+
+// projectMemberOptions are used to add and edit project members, the vendored
+// options do not support expiry dates
+type projectMemberOptions struct {
+	UserID      *int                     `url:"user_id,omitempty" json:"user_id,omitempty"`
+	AccessLevel *gitlab.AccessLevelValue `url:"access_level,omitempty" json:"access_level,omitempty"`
+	ExpiresAt   *string                  `url:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+const accessLevelsDescription = `
+  Access Levels:
+
+	10 = Guest Permissions
+	20 = Reporter Permissions
+	30 = Developer Permissions
+	40 = Master Permissions`
+
+// see https://docs.gitlab.com/ce/api/members.html
+var projectMembersCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:   "project-members",
+		Short: "Access project members",
+		Long:  `Show and manage members and access level of projects`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/members.html#list-all-members-of-a-group-or-project
+type projectMembersListFlags struct {
+	Id    *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Query *string `flag_name:"query" short:"q" type:"string" required:"no" description:"A query string to search for members"`
+}
+
+var projectMembersListCmd = &golabCommand{
+	Parent: projectMembersCmd.Cmd,
+	Flags:  &projectMembersListFlags{},
+	Opts:   &gitlab.ListProjectMembersOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List all members of a project",
+		Long:  `Gets a list of project members viewable by the authenticated user`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*projectMembersListFlags)
+		opts := cmd.Opts.(*gitlab.ListProjectMembersOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			var members []*projectMember
+			resp, err := gitlabRequest("GET", projectPath(*flags.Id, "/members"), opts, &members)
+			return members, resp, err
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/members.html#get-a-member-of-a-group-or-project
+type projectMembersGetFlags struct {
+	Id     *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	UserId *int    `flag_name:"user_id" short:"u" type:"integer" required:"yes" description:"The user ID of the member"`
+}
+
+var projectMembersGetCmd = &golabCommand{
+	Parent: projectMembersCmd.Cmd,
+	Flags:  &projectMembersGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get a member of a project",
+		Long:  `Gets a member of a project`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*projectMembersGetFlags)
+		member := &projectMember{}
+		_, err := gitlabRequest("GET", projectPath(*flags.Id, "/members/%d", *flags.UserId), nil, member)
+		if err != nil {
+			return err
+		}
+		return OutputJson(member)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/members.html#add-a-member-to-a-group-or-project
+type projectMembersAddFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	UserID      *int    `flag_name:"user_id" short:"u" type:"integer" required:"yes" description:"The user ID of the new member"`
+	AccessLevel *string `flag_name:"access_level" short:"a" type:"integer" transform:"str2AccessLevel" required:"yes" description:"A valid access level"`
+	ExpiresAt   *string `flag_name:"expires_at" type:"string" required:"no" description:"A date string in the format YEAR-MONTH-DAY"`
+}
+
+var projectMembersAddCmd = &golabCommand{
+	Parent: projectMembersCmd.Cmd,
+	Flags:  &projectMembersAddFlags{},
+	Opts:   &projectMemberOptions{},
+	Cmd: &cobra.Command{
+		Use:   "add",
+		Short: "Add a member to a project",
+		Long:  "Adds a member to a project\n" + accessLevelsDescription,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*projectMembersAddFlags)
+		opts := cmd.Opts.(*projectMemberOptions)
+		member := &projectMember{}
+		_, err := gitlabRequest("POST", projectPath(*flags.Id, "/members"), opts, member)
+		if err != nil {
+			return err
+		}
+		return OutputJson(member)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/members.html#edit-a-member-of-a-group-or-project
+type projectMembersEditFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	UserId      *int    `flag_name:"user_id" short:"u" type:"integer" required:"yes" description:"The user ID of the member"`
+	AccessLevel *string `flag_name:"access_level" short:"a" type:"integer" transform:"str2AccessLevel" required:"yes" description:"A valid access level"`
+	ExpiresAt   *string `flag_name:"expires_at" type:"string" required:"no" description:"A date string in the format YEAR-MONTH-DAY"`
+}
+
+var projectMembersEditCmd = &golabCommand{
+	Parent: projectMembersCmd.Cmd,
+	Flags:  &projectMembersEditFlags{},
+	Opts:   &projectMemberOptions{},
+	Cmd: &cobra.Command{
+		Use:   "edit",
+		Short: "Edit a member of a project",
+		Long:  "Updates a member of a project\n" + accessLevelsDescription,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*projectMembersEditFlags)
+		opts := cmd.Opts.(*projectMemberOptions)
+		member := &projectMember{}
+		_, err := gitlabRequest("PUT", projectPath(*flags.Id, "/members/%d", *flags.UserId), opts, member)
+		if err != nil {
+			return err
+		}
+		return OutputJson(member)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/members.html#remove-a-member-from-a-group-or-project
+type projectMembersDeleteFlags struct {
+	Id     *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	UserId *int    `flag_name:"user_id" short:"u" type:"integer" required:"yes" description:"The user ID of the member"`
+}
+
+var projectMembersDeleteCmd = &golabCommand{
+	Parent: projectMembersCmd.Cmd,
+	Flags:  &projectMembersDeleteFlags{},
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Remove a member from a project",
+		Long:  `Removes a user from a project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*projectMembersDeleteFlags)
+		_, err := gitlabClient.ProjectMembers.DeleteProjectMember(*flags.Id, *flags.UserId)
+		return err
+	},
+}
+
+type projectMembersSyncFlags struct {
+	Id            *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project to copy members to"`
+	SourceGroup   *string `flag_name:"source_group" short:"g" type:"integer/string" required:"no" description:"The ID or URL-encoded path of the group to copy members from"`
+	SourceProject *string `flag_name:"source_project" short:"s" type:"integer/string" required:"no" description:"The ID or URL-encoded path of the project to copy members from"`
+	Remove        *bool   `flag_name:"remove" short:"r" type:"boolean" required:"no" description:"Remove members in target project that don't exist in source group or project"`
+}
+
+var projectMembersSyncCmd = &golabCommand{
+	Parent: projectMembersCmd.Cmd,
+	Flags:  &projectMembersSyncFlags{},
+	Cmd: &cobra.Command{
+		Use:   "sync",
+		Short: "Synchronizes members of a group or project into a project",
+		Long: `Synchronizes the members of a group or project into a project, by either
+
+* merging them (default) - members that exist in target project but not in source are kept
+* removing them (--remove) - members that exist in target project but not in source are deleted
+
+Members are added with their access level and expiry date in the source, group owners become masters of the project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*projectMembersSyncFlags)
+		var source string
+		switch {
+		case flags.SourceGroup != nil && flags.SourceProject != nil:
+			return errors.New("only one of --source_group or --source_project can be given")
+		case flags.SourceGroup != nil:
+			source = "groups/" + url.QueryEscape(*flags.SourceGroup) + "/members"
+		case flags.SourceProject != nil:
+			source = projectPath(*flags.SourceProject, "/members")
+		default:
+			return errors.New("required flag --source_group or --source_project was empty")
+		}
+		target := projectPath(*flags.Id, "/members")
+		if err := syncProjectMembers(source, target, flags.Remove != nil && *flags.Remove); err != nil {
+			return err
+		}
+		members, err := listAllMembers(target)
+		if err != nil {
+			return err
+		}
+		return OutputJson(members)
+	},
+}
+
+// syncProjectMembers adds the members of source that are missing in target,
+// members of target that are not in source are removed if remove is true.
+// source and target are the API paths of the members of a group or project.
+func syncProjectMembers(source string, target string, remove bool) error {
+	sourceMembers, err := listAllMembers(source)
+	if err != nil {
+		return err
+	}
+	targetMembers, err := listAllMembers(target)
+	if err != nil {
+		return err
+	}
+	inTarget := map[int]bool{}
+	for _, member := range targetMembers {
+		inTarget[member.ID] = true
+	}
+	inSource := map[int]bool{}
+	for _, member := range sourceMembers {
+		inSource[member.ID] = true
+		if inTarget[member.ID] {
+			continue
+		}
+		accessLevel := member.AccessLevel
+		if accessLevel > gitlab.MasterPermissions {
+			accessLevel = gitlab.MasterPermissions // projects have no owners
+		}
+		opts := &projectMemberOptions{UserID: gitlab.Int(member.ID), AccessLevel: &accessLevel}
+		if member.ExpiresAt != nil {
+			opts.ExpiresAt = gitlab.String(time.Time(*member.ExpiresAt).Format("2006-01-02"))
+		}
+		if _, err := gitlabRequest("POST", target, opts, nil); err != nil {
+			return err
+		}
+	}
+	if !remove {
+		return nil
+	}
+	for _, member := range targetMembers {
+		if inSource[member.ID] {
+			continue
+		}
+		if _, err := gitlabRequest("DELETE", target+"/"+strconv.Itoa(member.ID), nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listAllMembers returns the members of a group or project from all result
+// pages, path is the API path of the members
+func listAllMembers(path string) ([]*projectMember, error) {
+	var members []*projectMember
+	opts := &gitlab.ListProjectMembersOptions{}
+	err := forEachPage(&opts.ListOptions, func() (*gitlab.Response, error) {
+		var page []*projectMember
+		resp, err := gitlabRequest("GET", path, opts, &page)
+		members = append(members, page...)
+		return resp, err
+	})
+	return members, err
+}
+
+func init() {
+	projectMembersCmd.Init()
+	projectMembersListCmd.Init()
+	projectMembersGetCmd.Init()
+	projectMembersAddCmd.Init()
+	projectMembersEditCmd.Init()
+	projectMembersDeleteCmd.Init()
+	projectMembersSyncCmd.Init()
+	AddDefaultColumns(projectMember{}, "id", "username", "name", "access_level", "expires_at")
+}
@@ -0,0 +1,89 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("project-members command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when the `sync` sub command is executed without source", func() {
+		It("should exit with error", func() {
+			_, _, err := executeCommand(RootCmd, "project-members", "sync", "-i", "7")
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal("required flag --source_group or --source_project was empty"))
+		})
+	})
+
+	Context("when the `sync` sub command is executed with a source group and `--remove`", func() {
+		It("adds missing members and removes members that are not in the group", func() {
+			var requests []string
+			mux.HandleFunc("/api/v4/groups/my-group/members", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"id":1,"access_level":30},{"id":2,"access_level":50,"expires_at":"2018-12-31"}]`)
+			})
+			mux.HandleFunc("/api/v4/projects/7/members", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					fmt.Fprint(w, `[{"id":1,"access_level":40},{"id":3,"access_level":30}]`)
+					return
+				}
+				var body map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&body)
+				requests = append(requests, fmt.Sprintf("%s %v %v %v", r.Method, body["user_id"], body["access_level"], body["expires_at"]))
+				fmt.Fprint(w, `{}`)
+			})
+			mux.HandleFunc("/api/v4/projects/7/members/", func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+			})
+
+			_, _, err := executeCommand(RootCmd, "project-members", "sync", "-i", "7", "-g", "my-group", "-r")
+			Expect(err).To(BeNil())
+			Expect(requests).To(Equal([]string{
+				"POST 2 40 2018-12-31",
+				"DELETE /api/v4/projects/7/members/3",
+			}))
+		})
+	})
+})
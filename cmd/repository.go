@@ -0,0 +1,297 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// see https://docs.gitlab.com/ce/api/repositories.html
+var repoCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "repo",
+		Aliases: []string{"repository"},
+		Short:   "Browse repositories",
+		Long:    `Inspect the tree, blobs, archives, commit ranges and contributors of a project's repository without cloning it`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/repositories.html#list-repository-tree
+type repoTreeFlags struct {
+	Id        *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Path      *string `flag_name:"path" short:"p" type:"string" required:"no" description:"The path inside repository. Used to get content of subdirectories"`
+	Ref       *string `flag_name:"ref" short:"r" type:"string" required:"no" description:"The name of a repository branch or tag or if not given the default branch"`
+	Recursive *bool   `flag_name:"recursive" type:"boolean" required:"no" description:"Boolean value used to get a recursive tree (false by default)"`
+}
+
+// listTreeOptions adds pagination to the vendored ListTreeOptions, the tree
+// API returns 20 nodes per page by default
+type listTreeOptions struct {
+	gitlab.ListOptions
+	Path      *string `url:"path,omitempty" json:"path,omitempty"`
+	Ref       *string `url:"ref,omitempty" json:"ref,omitempty"`
+	Recursive *bool   `url:"recursive,omitempty" json:"recursive,omitempty"`
+}
+
+var repoTreeCmd = &golabCommand{
+	Parent: repoCmd.Cmd,
+	Flags:  &repoTreeFlags{},
+	Opts:   &listTreeOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "tree",
+		Short: "List repository tree",
+		Long:  `Get a list of repository files and directories in a project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*repoTreeFlags)
+		opts := cmd.Opts.(*listTreeOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			var nodes []*gitlab.TreeNode
+			resp, err := gitlabRequest("GET", projectPath(*flags.Id, "/repository/tree"), opts, &nodes)
+			return nodes, resp, err
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/repositories.html#raw-blob-content
+type repoBlobFlags struct {
+	Id *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+}
+
+var repoBlobCmd = &golabCommand{
+	Parent: repoCmd.Cmd,
+	Flags:  &repoBlobFlags{},
+	Cmd: &cobra.Command{
+		Use:   "blob <sha>",
+		Short: "Raw blob content",
+		Long:  `Prints the raw file contents for a blob by blob SHA, e.g. as listed by 'golab repo tree'`,
+		Args:  cobra.ExactArgs(1),
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*repoBlobFlags)
+		content, _, err := gitlabClient.Repositories.RawBlobContent(*flags.Id, cmd.Args[0])
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(content)
+		return err
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/repositories.html#get-file-archive
+type repoArchiveFlags struct {
+	Id     *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Ref    *string `flag_name:"ref" short:"r" type:"string" required:"no" description:"The commit SHA, branch or tag to download, defaults to the tip of the default branch"`
+	Format *string `flag_name:"format" type:"string" required:"no" description:"The archive format: tar.gz (default), tar.bz2, tar or zip"`
+	File   *string `flag_name:"file" short:"f" type:"string" required:"no" description:"The file to write the archive to, '-' writes to stdout. Defaults to <project>-<ref>.<format>"`
+}
+
+var repoArchiveCmd = &golabCommand{
+	Parent: repoCmd.Cmd,
+	Flags:  &repoArchiveFlags{},
+	Cmd: &cobra.Command{
+		Use:   "archive",
+		Short: "Get file archive",
+		Long: `Download an archive of the repository and print the name of the written file.
+
+The file is given with -f/--file, as -o is the global --output flag.
+
+Example:
+
+    golab repo archive -i my-group/my-project -r v1.0.0 --format zip -f release.zip`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*repoArchiveFlags)
+		format := "tar.gz"
+		if flags.Format != nil {
+			format = *flags.Format
+		}
+		ref := ""
+		if flags.Ref != nil {
+			ref = *flags.Ref
+		}
+		file := archiveFileName(*flags.Id, ref, format)
+		if flags.File != nil {
+			file = *flags.File
+		}
+		if file == "-" {
+			return downloadArchive(*flags.Id, ref, format, os.Stdout)
+		}
+		out, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		err = downloadArchive(*flags.Id, ref, format, out)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(file)
+			return err
+		}
+		fmt.Println(file)
+		return nil
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/repositories.html#compare-branches-tags-or-commits
+type repoCompareFlags struct {
+	Id   *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	From *string `flag_name:"from" type:"string" required:"yes" description:"The commit SHA or branch name"`
+	To   *string `flag_name:"to" type:"string" required:"yes" description:"The commit SHA or branch name"`
+}
+
+// comparison is the output of 'repo compare', diffs are reduced to their
+// number of added and deleted lines
+type comparison struct {
+	Commits   []*gitlab.Commit `json:"commits"`
+	Files     []*diffStat      `json:"files"`
+	Additions int              `json:"additions"`
+	Deletions int              `json:"deletions"`
+}
+
+type diffStat struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+	Additions   int    `json:"additions"`
+	Deletions   int    `json:"deletions"`
+}
+
+var repoCompareCmd = &golabCommand{
+	Parent: repoCmd.Cmd,
+	Flags:  &repoCompareFlags{},
+	Opts:   &gitlab.CompareOptions{},
+	Cmd: &cobra.Command{
+		Use:   "compare",
+		Short: "Compare branches, tags or commits",
+		Long:  `List the commits between two branches, tags or commits and the number of added and deleted lines per changed file.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*repoCompareFlags)
+		opts := cmd.Opts.(*gitlab.CompareOptions)
+		compare, _, err := gitlabClient.Repositories.Compare(*flags.Id, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(compareStats(compare))
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/repositories.html#contributors
+type repoContributorsFlags struct {
+	Id *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+}
+
+var repoContributorsCmd = &golabCommand{
+	Parent: repoCmd.Cmd,
+	Flags:  &repoContributorsFlags{},
+	Cmd: &cobra.Command{
+		Use:   "contributors",
+		Short: "Contributors",
+		Long:  `Get repository contributors list.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*repoContributorsFlags)
+		contributors, _, err := gitlabClient.Repositories.Contributors(*flags.Id)
+		if err != nil {
+			return err
+		}
+		return OutputJson(contributors)
+	},
+}
+
+// downloadArchive writes the archive of ref in the given format to out, the
+// vendored Archive does not support formats
+func downloadArchive(pid string, ref string, format string, out io.Writer) error {
+	opts := &gitlab.ArchiveOptions{}
+	if ref != "" {
+		opts.SHA = &ref
+	}
+	_, err := gitlabRequest("GET", projectPath(pid, "/repository/archive.%s", format), opts, out)
+	return err
+}
+
+func archiveFileName(pid string, ref string, format string) string {
+	name := path.Base(pid)
+	if ref != "" {
+		name += "-" + strings.Replace(ref, "/", "-", -1)
+	}
+	return name + "." + format
+}
+
+func compareStats(compare *gitlab.Compare) *comparison {
+	result := &comparison{Commits: compare.Commits, Files: []*diffStat{}}
+	for _, diff := range compare.Diffs {
+		stat := &diffStat{
+			OldPath:     diff.OldPath,
+			NewPath:     diff.NewPath,
+			NewFile:     diff.NewFile,
+			RenamedFile: diff.RenamedFile,
+			DeletedFile: diff.DeletedFile,
+		}
+		// lines before the first hunk are file headers of older Gitlab versions
+		inHunk := false
+		for _, line := range strings.Split(diff.Diff, "\n") {
+			switch {
+			case strings.HasPrefix(line, "@@"):
+				inHunk = true
+			case !inHunk:
+			case strings.HasPrefix(line, "+"):
+				stat.Additions++
+			case strings.HasPrefix(line, "-"):
+				stat.Deletions++
+			}
+		}
+		result.Additions += stat.Additions
+		result.Deletions += stat.Deletions
+		result.Files = append(result.Files, stat)
+	}
+	return result
+}
+
+func init() {
+	AddDefaultColumns(gitlab.TreeNode{}, "type", "path", "mode")
+	AddDefaultColumns(gitlab.Contributor{}, "name", "email", "commits", "additions", "deletions")
+	repoCmd.Init()
+	repoTreeCmd.Init()
+	repoBlobCmd.Init()
+	repoArchiveCmd.Init()
+	repoCompareCmd.Init()
+	repoContributorsCmd.Init()
+}
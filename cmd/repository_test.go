@@ -0,0 +1,108 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("repo command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `tree` sub command is executed", func() {
+		It("lists the tree of the given path and ref", func() {
+			mux.HandleFunc("/api/v4/projects/42/repository/tree", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("path")).To(Equal("docs"))
+				Expect(r.URL.Query().Get("ref")).To(Equal("develop"))
+				Expect(r.URL.Query().Get("recursive")).To(Equal("true"))
+				fmt.Fprint(w, `[{"id":"a1b2","name":"index.md","type":"blob","path":"docs/index.md","mode":"100644"}]`)
+			})
+
+			out, _, err := executeCommand(RootCmd, "repo", "tree", "-i", "42", "-p", "docs", "-r", "develop", "--recursive")
+			Expect(err).To(BeNil())
+			Expect(out).To(ContainSubstring(`"path": "docs/index.md"`))
+		})
+	})
+
+	Context("when the `archive` sub command is executed", func() {
+		It("writes the archive in the given format to stdout", func() {
+			mux.HandleFunc("/api/v4/projects/42/repository/archive.zip", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("sha")).To(Equal("v1.0.0"))
+				fmt.Fprint(w, "zip content")
+			})
+
+			out, _, err := executeCommand(RootCmd, "repo", "archive", "-i", "42", "-r", "v1.0.0", "--format", "zip", "-f", "-")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("zip content"))
+		})
+	})
+
+	Context("when the `compare` sub command is executed", func() {
+		It("outputs the commits and the diff stat", func() {
+			mux.HandleFunc("/api/v4/projects/42/repository/compare", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Query().Get("from")).To(Equal("master"))
+				Expect(r.URL.Query().Get("to")).To(Equal("feature"))
+				fmt.Fprint(w, `{"commits":[{"id":"12345678"}],"diffs":[
+					{"old_path":"a.txt","new_path":"a.txt","diff":"--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,4 @@\n-old\n--- removed comment\n+new\n+more\n+++ added\n same\n"},
+					{"old_path":"b.txt","new_path":"b.txt","new_file":true,"diff":"@@ -0,0 +1 @@\n+b\n"}]}`)
+			})
+
+			out, _, err := executeCommand(RootCmd, "repo", "compare", "-i", "42", "--from", "master", "--to", "feature")
+			Expect(err).To(BeNil())
+			var result comparison
+			Expect(json.Unmarshal([]byte(out), &result)).To(Succeed())
+			Expect(result.Commits).To(HaveLen(1))
+			Expect(result.Additions).To(Equal(4))
+			Expect(result.Deletions).To(Equal(2))
+			Expect(*result.Files[0]).To(Equal(diffStat{OldPath: "a.txt", NewPath: "a.txt", Additions: 3, Deletions: 2}))
+			Expect(result.Files[1].NewFile).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("archiveFileName", func() {
+	It("is named after the project, the ref and the format", func() {
+		Expect(archiveFileName("my-group/my-project", "release/1.0", "zip")).To(Equal("my-project-release-1.0.zip"))
+		Expect(archiveFileName("42", "", "tar.gz")).To(Equal("42.tar.gz"))
+	})
+})
@@ -26,6 +26,9 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
 
 	"github.com/hashicorp/go-cleanhttp"
 	"github.com/hashicorp/go-rootcerts"
@@ -34,15 +37,34 @@ import (
 	"github.com/xanzy/go-gitlab"
 )
 
-var cfgFile, caFile, caPath string
+var cfgFile, caFile, caPath, envName, outputFormat string
+
+var outputColumns []string
+
+const envUsage = "(optional) name of the environment in the golab config file to run the command against (default is $GOLAB_ENV or current_env in config file)"
 
 var gitlabClient *gitlab.Client
 
+// gitlabClientErr holds the error that occurred when initializing the Gitlab
+// client, e.g. for an unknown environment. It is returned by all commands
+// except the ones in offlineCommands.
+var gitlabClientErr error
+
+// offlineCommands are top-level commands that work without a Gitlab client,
+// e.g. to fix the config that broke initializing the client
+var offlineCommands = map[string]bool{"env": true, "gendoc": true, "help": true, "login": true, "zsh-completion": true}
+
 var RootCmd = &cobra.Command{
 	Use:               "golab",
 	Short:             "Gitlab CLI written in Go",
 	Long:              `This application provides a Command Line Interface for Gitlab.`,
 	DisableAutoGenTag: true, // disables footer in markdown files generated by cobra.gendoc
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if gitlabClientErr != nil && !offlineCommands[topLevelCommand(cmd).Name()] {
+			return gitlabClientErr
+		}
+		return SetOutputFormat(outputFormat, outputColumns)
+	},
 }
 
 func Execute() {
@@ -56,6 +78,10 @@ func initRootCommand() {
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "(optional) golab config file (default is ./.golab.yml and $HOME/.golab.yml)")
 	RootCmd.PersistentFlags().StringVar(&caFile, "ca-file", "", "(optional) provides a .pem file to be used in certificates pool for SSL connection")
 	RootCmd.PersistentFlags().StringVar(&caPath, "ca-path", "", "(optional) provides a directory with .pem certificates to be used for SSL connection")
+	RootCmd.PersistentFlags().StringVarP(&envName, "env", "e", "", envUsage)
+	shadowEnvShorthand(RootCmd)
+	RootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "json", "(optional) output format: "+strings.Join(OutputFormats, ", "))
+	RootCmd.PersistentFlags().StringSliceVar(&outputColumns, "columns", nil, "(optional) comma-separated list of fields shown in table, csv and tsv output, e.g. id,name,author.username")
 
 	// TODO this is an ugly hack to prevent re-initialization when mocked in testing
 	if gitlabClient == nil {
@@ -83,10 +109,28 @@ func initConfig() {
 	}
 }
 
+// shadowEnvShorthand registers a long-only --env flag on all commands that
+// already use -e as shorthand for one of their own flags. Otherwise cobra
+// panics when merging the global --env / -e flag into their flag set.
+func shadowEnvShorthand(cmd *cobra.Command) {
+	for _, c := range cmd.Commands() {
+		if c.PersistentFlags().ShorthandLookup("e") != nil || c.Flags().ShorthandLookup("e") != nil {
+			c.Flags().StringVar(&envName, "env", "", envUsage)
+		}
+		shadowEnvShorthand(c)
+	}
+}
+
 func initGitlabClient() {
-	baseUrl, err := url.Parse(viper.GetString("url"))
+	host, token, err := gitlabTarget()
+	if err != nil {
+		gitlabClientErr = err
+	}
+
+	baseUrl, err := url.Parse(host)
 	if err != nil {
-		fmt.Printf("Could not parse given URL '%s': %s", baseUrl, err)
+		gitlabClientErr = fmt.Errorf("could not parse given URL '%s': %s", host, err)
+		baseUrl = &url.URL{}
 	}
 
 	httpClient, err := initHttpClient()
@@ -94,10 +138,54 @@ func initGitlabClient() {
 		panic("Error in initializing http client " + err.Error())
 	}
 
-	gitlabClient = gitlab.NewClient(httpClient, viper.GetString("token"))
+	gitlabClient = gitlab.NewClient(httpClient, token)
 	gitlabClient.SetBaseURL(baseUrl.String() + "/api/v4")
 }
 
+// topLevelCommand returns the ancestor of cmd that is a direct child of the
+// root command or cmd itself, if it is the root command
+func topLevelCommand(cmd *cobra.Command) *cobra.Command {
+	for cmd.HasParent() && cmd.Parent().HasParent() {
+		cmd = cmd.Parent()
+	}
+	return cmd
+}
+
+// gitlabTarget returns url and token of the selected environment or the
+// top-level url and token of the config file, if no environment is selected
+func gitlabTarget() (string, string, error) {
+	conf, err := currentGolabConfig()
+	if err != nil {
+		return "", "", err
+	}
+	name := selectedEnvironment(conf)
+	if name == "" {
+		return viper.GetString("url"), viper.GetString("token"), nil
+	}
+	env, ok := conf.environment(name)
+	if !ok {
+		return "", "", fmt.Errorf("environment '%s' is not configured in %s", name, conf.filename)
+	}
+	return env.Url, env.Token, nil
+}
+
+// explicitEnvironment returns the environment given by --env or $GOLAB_ENV
+func explicitEnvironment() string {
+	if envName != "" {
+		return envName
+	}
+	return os.Getenv("GOLAB_ENV")
+}
+
+// selectedEnvironment returns the environment given by --env, $GOLAB_ENV or
+// the current_env entry of the config file - in this order
+func selectedEnvironment(conf *golabConfig) string {
+	if env := explicitEnvironment(); env != "" {
+		return env
+	}
+	return conf.currentEnvironment()
+}
+
 func initHttpClient() (*http.Client, error) {
 	// see https://github.com/hashicorp/go-rootcerts
 	tlsConfig := &tls.Config{}
@@ -0,0 +1,49 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("root command", func() {
+
+	AfterEach(func() {
+		gitlabClientErr = nil
+	})
+
+	It("fails commands that need a Gitlab client, if the client could not be initialized", func() {
+		resetCommandLineFlagSet()
+		gitlabClientErr = errors.New("unknown environment staging")
+
+		_, _, err := executeCommand(RootCmd, "project", "ls")
+		Expect(err).To(MatchError("unknown environment staging"))
+	})
+
+	It("finds the top-level command of a sub command", func() {
+		Expect(topLevelCommand(envListCmd.Cmd).Name()).To(Equal("env"))
+		Expect(topLevelCommand(envCmd.Cmd).Name()).To(Equal("env"))
+		Expect(topLevelCommand(RootCmd).Name()).To(Equal("golab"))
+	})
+})
@@ -0,0 +1,286 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// applicationSettings are kept generic, so that all settings of an instance
+// can be read and changed, no matter which Gitlab version it runs
+type applicationSettings map[string]interface{}
+
+// readOnlySettings are returned by the API, but cannot be changed. They are
+// ignored in settings files, e.g. when applying the output of 'settings get'.
+var readOnlySettings = map[string]bool{"id": true, "created_at": true, "updated_at": true}
+
+// see https://docs.gitlab.com/ce/api/settings.html
+var settingsCmd = &golabCommand{
+	Parent: adminCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "settings",
+		Short: "Manage application settings",
+		Long:  `Get and change the application settings of the Gitlab instance and keep them in sync with a settings file`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/settings.html#get-current-application-settings
+var settingsGetCmd = &golabCommand{
+	Parent: settingsCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get current application settings",
+		Long:  `List the current application settings of the Gitlab instance.`,
+	},
+	Run: func(cmd golabCommand) error {
+		settings, err := getApplicationSettings()
+		if err != nil {
+			return err
+		}
+		return OutputJson(settings)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/settings.html#change-application-settings
+var settingsSetCmd = &golabCommand{
+	Parent: settingsCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "set key=value...",
+		Short: "Change application settings",
+		Long: `Change one or more application settings. Values are converted to the type of the current value of a setting, lists are separated by comma.
+
+Example:
+
+    golab admin settings set signup_enabled=false default_projects_limit=20 domain_whitelist=example.com,example.org`,
+		Args: cobra.MinimumNArgs(1),
+	},
+	Run: func(cmd golabCommand) error {
+		current, err := getApplicationSettings()
+		if err != nil {
+			return err
+		}
+		changes := applicationSettings{}
+		for _, arg := range cmd.Args {
+			parts := strings.SplitN(arg, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return fmt.Errorf("invalid setting %s, expected key=value", arg)
+			}
+			value, err := settingValue(parts[1], current[parts[0]])
+			if err != nil {
+				return fmt.Errorf("invalid value for %s: %s", parts[0], err)
+			}
+			changes[parts[0]] = value
+		}
+		settings, err := updateApplicationSettings(changes)
+		if err != nil {
+			return err
+		}
+		return OutputJson(settings)
+	},
+}
+
+type settingsFileFlags struct {
+	File *string `flag_name:"file" short:"f" type:"string" required:"yes" description:"YAML or JSON file with the desired settings, '-' reads from stdin"`
+}
+
+var settingsDiffCmd = &golabCommand{
+	Parent: settingsCmd.Cmd,
+	Flags:  &settingsFileFlags{},
+	Cmd: &cobra.Command{
+		Use:   "diff",
+		Short: "Compare application settings with a settings file",
+		Long: `Print the settings of a settings file whose values differ from the current application settings as
+
+    key: current value -> desired value
+
+Settings that are not in the file and read-only settings like updated_at are ignored.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*settingsFileFlags)
+		changes, err := settingsChanges(*flags.File)
+		if err != nil {
+			return err
+		}
+		printSettingsChanges(changes)
+		return nil
+	},
+}
+
+var settingsApplyCmd = &golabCommand{
+	Parent: settingsCmd.Cmd,
+	Flags:  &settingsFileFlags{},
+	Cmd: &cobra.Command{
+		Use:   "apply",
+		Short: "Apply a settings file to the application settings",
+		Long: `Update the application settings whose values differ from the settings in a settings file and print the changed settings like 'admin settings diff'.
+
+Example:
+
+    golab admin settings get -o yaml > settings.yaml
+    golab admin settings apply -f settings.yaml -e production`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*settingsFileFlags)
+		changes, err := settingsChanges(*flags.File)
+		if err != nil {
+			return err
+		}
+		if len(changes) > 0 {
+			desired := applicationSettings{}
+			for _, change := range changes {
+				desired[change.key] = change.desired
+			}
+			if _, err := updateApplicationSettings(desired); err != nil {
+				return err
+			}
+		}
+		printSettingsChanges(changes)
+		return nil
+	},
+}
+
+type settingChange struct {
+	key     string
+	current interface{}
+	desired interface{}
+}
+
+// settingsChanges returns the settings in file that differ from the current
+// application settings, sorted by key
+func settingsChanges(file string) ([]settingChange, error) {
+	content, err := readFileOrStdin(file)
+	if err != nil {
+		return nil, err
+	}
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
+		return nil, err
+	}
+	desired, ok := normalizeYaml(parsed).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("settings file has to contain a map of settings")
+	}
+	current, err := getApplicationSettings()
+	if err != nil {
+		return nil, err
+	}
+	var changes []settingChange
+	for key, value := range desired {
+		if !readOnlySettings[key] && !reflect.DeepEqual(value, current[key]) {
+			changes = append(changes, settingChange{key: key, current: current[key], desired: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].key < changes[j].key })
+	return changes, nil
+}
+
+func printSettingsChanges(changes []settingChange) {
+	if len(changes) == 0 {
+		fmt.Println("application settings are up to date")
+	}
+	for _, change := range changes {
+		current, _ := json.Marshal(change.current)
+		desired, _ := json.Marshal(change.desired)
+		fmt.Printf("%s: %s -> %s\n", change.key, current, desired)
+	}
+}
+
+// normalizeYaml converts parsed YAML into the types of parsed JSON, so that
+// it can be compared with the settings returned by the API
+func normalizeYaml(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for key, item := range v {
+			m[fmt.Sprint(key)] = normalizeYaml(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYaml(item)
+		}
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	}
+	return value
+}
+
+// settingValue converts value to the type of the current value of a setting,
+// values of unknown settings are parsed as JSON or taken as string
+func settingValue(value string, current interface{}) (interface{}, error) {
+	switch current.(type) {
+	case bool:
+		return strconv.ParseBool(value)
+	case float64:
+		return strconv.ParseFloat(value, 64)
+	case []interface{}:
+		if value == "" {
+			return []string{}, nil
+		}
+		return strings.Split(value, ","), nil
+	case string:
+		return value, nil
+	}
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(value), &parsed); err == nil {
+		return parsed, nil
+	}
+	return value, nil
+}
+
+func getApplicationSettings() (applicationSettings, error) {
+	settings := applicationSettings{}
+	_, err := gitlabRequest("GET", "application/settings", nil, &settings)
+	return settings, err
+}
+
+func updateApplicationSettings(changes applicationSettings) (applicationSettings, error) {
+	settings := applicationSettings{}
+	_, err := gitlabRequest("PUT", "application/settings", changes, &settings)
+	return settings, err
+}
+
+func init() {
+	settingsCmd.Init()
+	settingsGetCmd.Init()
+	settingsSetCmd.Init()
+	settingsDiffCmd.Init()
+	settingsApplyCmd.Init()
+}
@@ -0,0 +1,89 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("admin settings command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `apply` sub command is executed", func() {
+		It("updates only the settings that differ from the file and ignores read-only settings", func() {
+			var body map[string]interface{}
+			mux.HandleFunc("/api/v4/application/settings", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "PUT" {
+					json.NewDecoder(r.Body).Decode(&body)
+				}
+				fmt.Fprint(w, `{"id":1,"updated_at":"2018-02-01T10:00:00.000Z","signup_enabled":true,"default_projects_limit":10,"domain_whitelist":["example.com"],"home_page_url":null}`)
+			})
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			w.WriteString("id: 1\nupdated_at: 2018-01-01T10:00:00.000Z\nsignup_enabled: false\ndefault_projects_limit: 10\ndomain_whitelist:\n  - example.com\n  - example.org\n")
+			w.Close()
+			os.Stdin = r
+
+			out, _, err := executeCommand(RootCmd, "admin", "settings", "apply", "-f", "-")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("domain_whitelist: [\"example.com\"] -> [\"example.com\",\"example.org\"]\nsignup_enabled: true -> false"))
+			Expect(body).To(Equal(map[string]interface{}{
+				"signup_enabled":   false,
+				"domain_whitelist": []interface{}{"example.com", "example.org"},
+			}))
+		})
+	})
+})
+
+var _ = Describe("settingValue", func() {
+	It("converts values to the type of the current value", func() {
+		Expect(settingValue("false", true)).To(Equal(false))
+		Expect(settingValue("20", float64(10))).To(Equal(float64(20)))
+		Expect(settingValue("a,b", []interface{}{})).To(Equal([]string{"a", "b"}))
+		Expect(settingValue("42", "")).To(Equal("42"))
+		Expect(settingValue("42", nil)).To(Equal(float64(42)))
+		Expect(settingValue("text", nil)).To(Equal("text"))
+	})
+})
@@ -0,0 +1,367 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// see https://docs.gitlab.com/ce/api/snippets.html
+var snippetsCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "snippets",
+		Aliases: []string{"snippet"},
+		Short:   "Manage snippets",
+		Long:    `Manage personal snippets of the authenticated user or, with --project, the snippets of a project`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/snippets.html#list-snippets
+type snippetsListFlags struct {
+	Project *string `flag_name:"project" short:"p" type:"integer/string" required:"no" description:"The ID or URL-encoded path of a project to list the project snippets of"`
+}
+
+var snippetsListCmd = &golabCommand{
+	Parent: snippetsCmd.Cmd,
+	Flags:  &snippetsListFlags{},
+	Opts:   &gitlab.ListSnippetsOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List snippets",
+		Long:  `Get a list of the current user's snippets or of the snippets of a project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*snippetsListFlags)
+		opts := cmd.Opts.(*gitlab.ListSnippetsOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			if flags.Project != nil {
+				return gitlabClient.ProjectSnippets.ListSnippets(*flags.Project, &gitlab.ListProjectSnippetsOptions{ListOptions: opts.ListOptions})
+			}
+			return gitlabClient.Snippets.ListSnippets(opts)
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/snippets.html#single-snippet
+type snippetsGetFlags struct {
+	Id      *int    `flag_name:"id" short:"i" type:"integer" required:"yes" description:"The ID of a snippet"`
+	Project *string `flag_name:"project" short:"p" type:"integer/string" required:"no" description:"The ID or URL-encoded path of the project of a project snippet"`
+}
+
+var snippetsGetCmd = &golabCommand{
+	Parent: snippetsCmd.Cmd,
+	Flags:  &snippetsGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Single snippet",
+		Long:  `Get a single snippet.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*snippetsGetFlags)
+		var snippet *gitlab.Snippet
+		var err error
+		if flags.Project != nil {
+			snippet, _, err = gitlabClient.ProjectSnippets.GetSnippet(*flags.Project, *flags.Id)
+		} else {
+			snippet, _, err = gitlabClient.Snippets.GetSnippet(*flags.Id)
+		}
+		if err != nil {
+			return err
+		}
+		return OutputJson(snippet)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/snippets.html#single-snippet-contents
+type snippetsRawFlags struct {
+	Id      *int    `flag_name:"id" short:"i" type:"integer" required:"yes" description:"The ID of a snippet"`
+	Project *string `flag_name:"project" short:"p" type:"integer/string" required:"no" description:"The ID or URL-encoded path of the project of a project snippet"`
+}
+
+var snippetsRawCmd = &golabCommand{
+	Parent: snippetsCmd.Cmd,
+	Flags:  &snippetsRawFlags{},
+	Cmd: &cobra.Command{
+		Use:   "raw",
+		Short: "Single snippet contents",
+		Long:  `Prints the raw content of a snippet.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*snippetsRawFlags)
+		var content []byte
+		var err error
+		if flags.Project != nil {
+			content, _, err = gitlabClient.ProjectSnippets.SnippetContent(*flags.Project, *flags.Id)
+		} else {
+			content, _, err = gitlabClient.Snippets.SnippetContent(*flags.Id)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(content)
+		return err
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/snippets.html#create-new-snippet
+type snippetsCreateFlags struct {
+	Project     *string `flag_name:"project" short:"p" type:"integer/string" required:"no" description:"The ID or URL-encoded path of a project to create a project snippet in"`
+	Title       *string `flag_name:"title" short:"t" type:"string" required:"yes" description:"The title of a snippet"`
+	FileName    *string `flag_name:"file_name" short:"n" type:"string" required:"yes" description:"The name of a snippet file"`
+	Description *string `flag_name:"description" short:"d" type:"string" required:"no" description:"The description of a snippet"`
+	Content     *string `flag_name:"content" short:"c" type:"string" required:"no" description:"The content of a snippet"`
+	ContentFile *string `flag_name:"content_file" short:"f" type:"string" required:"no" description:"Local file to read the content of the snippet from, '-' reads from stdin"`
+	Visibility  *string `flag_name:"visibility" short:"v" type:"string" transform:"string2visibility" required:"no" description:"The snippet's visibility: private (default), internal or public"`
+}
+
+var snippetsCreateCmd = &golabCommand{
+	Parent: snippetsCmd.Cmd,
+	Flags:  &snippetsCreateFlags{},
+	Opts:   &gitlab.CreateSnippetOptions{},
+	Cmd: &cobra.Command{
+		Use:   "create",
+		Short: "Create new snippet",
+		Long:  `Create a new snippet. The user must have permission to create new snippets.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*snippetsCreateFlags)
+		opts := cmd.Opts.(*gitlab.CreateSnippetOptions)
+		content, err := contentFromFlags(flags.Content, flags.ContentFile, "content", "content_file")
+		if err != nil {
+			return err
+		}
+		opts.Content = &content
+		snippet, err := createSnippet(flags.Project, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(snippet)
+	},
+}
+
+// createSnippet creates a personal snippet or, if pid is given, a project
+// snippet. Snippets are private unless a visibility is given.
+func createSnippet(pid *string, opts *gitlab.CreateSnippetOptions) (*gitlab.Snippet, error) {
+	if opts.Visibility == nil {
+		opts.Visibility = gitlab.Visibility(gitlab.PrivateVisibility)
+	}
+	if pid != nil {
+		snippet, _, err := gitlabClient.ProjectSnippets.CreateSnippet(*pid, &gitlab.CreateProjectSnippetOptions{
+			Title:       opts.Title,
+			FileName:    opts.FileName,
+			Description: opts.Description,
+			Code:        opts.Content,
+			Visibility:  opts.Visibility,
+		})
+		return snippet, err
+	}
+	snippet, _, err := gitlabClient.Snippets.CreateSnippet(opts)
+	return snippet, err
+}
+
+// see https://docs.gitlab.com/ce/api/snippets.html#update-snippet
+type snippetsEditFlags struct {
+	Id          *int    `flag_name:"id" short:"i" type:"integer" required:"yes" description:"The ID of a snippet"`
+	Project     *string `flag_name:"project" short:"p" type:"integer/string" required:"no" description:"The ID or URL-encoded path of the project of a project snippet"`
+	Title       *string `flag_name:"title" short:"t" type:"string" required:"no" description:"The title of a snippet"`
+	FileName    *string `flag_name:"file_name" short:"n" type:"string" required:"no" description:"The name of a snippet file"`
+	Description *string `flag_name:"description" short:"d" type:"string" required:"no" description:"The description of a snippet"`
+	Content     *string `flag_name:"content" short:"c" type:"string" required:"no" description:"The content of a snippet"`
+	ContentFile *string `flag_name:"content_file" short:"f" type:"string" required:"no" description:"Local file to read the content of the snippet from, '-' reads from stdin"`
+	Visibility  *string `flag_name:"visibility" short:"v" type:"string" transform:"string2visibility" required:"no" description:"The snippet's visibility: private, internal or public"`
+}
+
+var snippetsEditCmd = &golabCommand{
+	Parent: snippetsCmd.Cmd,
+	Flags:  &snippetsEditFlags{},
+	Opts:   &gitlab.UpdateSnippetOptions{},
+	Cmd: &cobra.Command{
+		Use:   "edit",
+		Short: "Update snippet",
+		Long:  `Update an existing snippet. The user must have permission to change an existing snippet.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*snippetsEditFlags)
+		opts := cmd.Opts.(*gitlab.UpdateSnippetOptions)
+		if flags.Content != nil || flags.ContentFile != nil {
+			content, err := contentFromFlags(flags.Content, flags.ContentFile, "content", "content_file")
+			if err != nil {
+				return err
+			}
+			opts.Content = &content
+		}
+		var snippet *gitlab.Snippet
+		var err error
+		if flags.Project != nil {
+			snippet, _, err = gitlabClient.ProjectSnippets.UpdateSnippet(*flags.Project, *flags.Id, &gitlab.UpdateProjectSnippetOptions{
+				Title:       opts.Title,
+				FileName:    opts.FileName,
+				Description: opts.Description,
+				Code:        opts.Content,
+				Visibility:  opts.Visibility,
+			})
+		} else {
+			snippet, _, err = gitlabClient.Snippets.UpdateSnippet(*flags.Id, opts)
+		}
+		if err != nil {
+			return err
+		}
+		return OutputJson(snippet)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/snippets.html#delete-snippet
+type snippetsDeleteFlags struct {
+	Id      *int    `flag_name:"id" short:"i" type:"integer" required:"yes" description:"The ID of a snippet"`
+	Project *string `flag_name:"project" short:"p" type:"integer/string" required:"no" description:"The ID or URL-encoded path of the project of a project snippet"`
+}
+
+var snippetsDeleteCmd = &golabCommand{
+	Parent: snippetsCmd.Cmd,
+	Flags:  &snippetsDeleteFlags{},
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Delete snippet",
+		Long:  `Delete an existing snippet.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*snippetsDeleteFlags)
+		var err error
+		if flags.Project != nil {
+			_, err = gitlabClient.ProjectSnippets.DeleteSnippet(*flags.Project, *flags.Id)
+		} else {
+			_, err = gitlabClient.Snippets.DeleteSnippet(*flags.Id)
+		}
+		return err
+	},
+}
+
+type pasteFlags struct {
+	Project     *string `flag_name:"project" short:"p" type:"integer/string" required:"no" description:"The ID or URL-encoded path of a project to create a project snippet in"`
+	Title       *string `flag_name:"title" short:"t" type:"string" required:"no" description:"The title of the snippet (default: the file name)"`
+	FileName    *string `flag_name:"file_name" short:"n" type:"string" required:"no" description:"The file name of the snippet, its extension determines the syntax highlighting (default: guessed from file or content)"`
+	Description *string `flag_name:"description" short:"d" type:"string" required:"no" description:"The description of the snippet"`
+	Visibility  *string `flag_name:"visibility" short:"v" type:"string" transform:"string2visibility" required:"no" description:"The snippet's visibility: private (default), internal or public"`
+}
+
+var pasteCmd = &golabCommand{
+	Parent: RootCmd,
+	Flags:  &pasteFlags{},
+	Opts:   &gitlab.CreateSnippetOptions{},
+	Cmd: &cobra.Command{
+		Use:   "paste [file]",
+		Short: "Create a snippet from a file or stdin",
+		Long: `Create a snippet from a file or, if no file is given, from stdin and print its URL.
+
+If no file name is given, the name of the file is used. For stdin the file extension is guessed from the content, so that the snippet is highlighted correctly.
+
+Example:
+
+    kubectl logs my-pod | golab paste -t "my-pod crash"`,
+		Args: cobra.MaximumNArgs(1),
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*pasteFlags)
+		opts := cmd.Opts.(*gitlab.CreateSnippetOptions)
+		file := "-"
+		if len(cmd.Args) == 1 {
+			file = cmd.Args[0]
+		}
+		content, err := readFileOrStdin(file)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(content) == "" {
+			return errors.New("nothing to paste, content is empty")
+		}
+		fileName := pasteFileName(file, content)
+		if flags.FileName != nil {
+			fileName = *flags.FileName
+		}
+		title := fileName
+		if flags.Title != nil {
+			title = *flags.Title
+		}
+		opts.FileName, opts.Title, opts.Content = &fileName, &title, &content
+		snippet, err := createSnippet(flags.Project, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Println(snippet.WebURL)
+		return nil
+	},
+}
+
+// pasteFileName returns the base name of file or, for stdin, a file name
+// with an extension guessed from the content
+func pasteFileName(file string, content string) string {
+	if file != "-" {
+		return filepath.Base(file)
+	}
+	trimmed := strings.TrimSpace(content)
+	firstLine := strings.SplitN(trimmed, "\n", 2)[0]
+	switch {
+	case strings.HasPrefix(firstLine, "#!"):
+		for _, interpreter := range [][]string{{"python", "py"}, {"ruby", "rb"}, {"node", "js"}, {"perl", "pl"}, {"sh", "sh"}} {
+			if strings.Contains(firstLine, interpreter[0]) {
+				return "paste." + interpreter[1]
+			}
+		}
+	case strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "["):
+		if json.Valid([]byte(trimmed)) {
+			return "paste.json"
+		}
+	case strings.HasPrefix(trimmed, "<?xml"):
+		return "paste.xml"
+	case strings.HasPrefix(trimmed, "diff --git") || strings.HasPrefix(trimmed, "--- "):
+		return "paste.diff"
+	case strings.HasPrefix(trimmed, "---"):
+		return "paste.yml"
+	}
+	return "paste.txt"
+}
+
+func init() {
+	snippetsCmd.Init()
+	snippetsListCmd.Init()
+	snippetsGetCmd.Init()
+	snippetsRawCmd.Init()
+	snippetsCreateCmd.Init()
+	snippetsEditCmd.Init()
+	snippetsDeleteCmd.Init()
+	pasteCmd.Init()
+	AddDefaultColumns(gitlab.Snippet{}, "id", "title", "file_name", "author.username", "web_url")
+}
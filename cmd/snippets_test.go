@@ -0,0 +1,101 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("paste command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when content is piped into `paste`", func() {
+		It("creates a private snippet with a guessed file name and prints its URL", func() {
+			var body map[string]string
+			mux.HandleFunc("/api/v4/snippets", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal("POST"))
+				json.NewDecoder(r.Body).Decode(&body)
+				fmt.Fprint(w, `{"id":7,"web_url":"https://gitlab.example.com/snippets/7"}`)
+			})
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			w.WriteString(`{"level":"error","msg":"connection refused"}`)
+			w.Close()
+			os.Stdin = r
+
+			out, _, err := executeCommand(RootCmd, "paste")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("https://gitlab.example.com/snippets/7"))
+			Expect(body["file_name"]).To(Equal("paste.json"))
+			Expect(body["title"]).To(Equal("paste.json"))
+			Expect(body["visibility"]).To(Equal("private"))
+		})
+	})
+
+	Context("when `paste` is executed with a file and `--project`", func() {
+		It("creates a project snippet named like the file", func() {
+			var body map[string]string
+			mux.HandleFunc("/api/v4/projects/1/snippets", func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&body)
+				fmt.Fprint(w, `{"id":8,"web_url":"https://gitlab.example.com/group/project/snippets/8"}`)
+			})
+			out, _, err := executeCommand(RootCmd, "paste", "-p", "1", "-v", "internal", "fixtures/commit-actions.json")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("https://gitlab.example.com/group/project/snippets/8"))
+			Expect(body["file_name"]).To(Equal("commit-actions.json"))
+			Expect(body["visibility"]).To(Equal("internal"))
+			Expect(body["code"]).NotTo(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("pasteFileName", func() {
+	It("guesses the extension from the content", func() {
+		Expect(pasteFileName("-", "#!/usr/bin/env python\nprint(1)")).To(Equal("paste.py"))
+		Expect(pasteFileName("-", "diff --git a/x b/x")).To(Equal("paste.diff"))
+		Expect(pasteFileName("-", "{not json")).To(Equal("paste.txt"))
+		Expect(pasteFileName("/tmp/build.log", "")).To(Equal("build.log"))
+	})
+})
@@ -0,0 +1,181 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+)
+
+// systemHook extends the vendored Hook with the events it is triggered for
+type systemHook struct {
+	ID                     int        `json:"id"`
+	URL                    string     `json:"url"`
+	CreatedAt              *time.Time `json:"created_at"`
+	PushEvents             bool       `json:"push_events"`
+	TagPushEvents          bool       `json:"tag_push_events"`
+	MergeRequestsEvents    bool       `json:"merge_requests_events"`
+	RepositoryUpdateEvents bool       `json:"repository_update_events"`
+	EnableSSLVerification  bool       `json:"enable_ssl_verification"`
+}
+
+// addSystemHookOptions adds the token and event toggles to the vendored
+// AddHookOptions
+type addSystemHookOptions struct {
+	URL                    *string `url:"url,omitempty" json:"url,omitempty"`
+	Token                  *string `url:"token,omitempty" json:"token,omitempty"`
+	PushEvents             *bool   `url:"push_events,omitempty" json:"push_events,omitempty"`
+	TagPushEvents          *bool   `url:"tag_push_events,omitempty" json:"tag_push_events,omitempty"`
+	MergeRequestsEvents    *bool   `url:"merge_requests_events,omitempty" json:"merge_requests_events,omitempty"`
+	RepositoryUpdateEvents *bool   `url:"repository_update_events,omitempty" json:"repository_update_events,omitempty"`
+	EnableSSLVerification  *bool   `url:"enable_ssl_verification,omitempty" json:"enable_ssl_verification,omitempty"`
+}
+
+// see https://docs.gitlab.com/ce/api/system_hooks.html
+var systemHooksCmd = &golabCommand{
+	Parent: adminCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:     "system-hooks",
+		Aliases: []string{"system-hook"},
+		Short:   "Manage system hooks",
+		Long:    `List, add, test and delete the system hooks of the Gitlab instance`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/system_hooks.html#list-system-hooks
+var systemHooksListCmd = &golabCommand{
+	Parent: systemHooksCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List system hooks",
+		Long:  `Get a list of all system hooks.`,
+	},
+	Run: func(cmd golabCommand) error {
+		var hooks []*systemHook
+		_, err := gitlabRequest("GET", "hooks", nil, &hooks)
+		if err != nil {
+			return err
+		}
+		return OutputJson(hooks)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/system_hooks.html#add-new-system-hook
+type systemHooksAddFlags struct {
+	URL                    *string `flag_name:"url" short:"u" type:"string" required:"yes" description:"The hook URL"`
+	Token                  *string `flag_name:"token" short:"t" type:"string" required:"no" description:"Secret token to validate received payloads; this will not be returned in the response"`
+	PushEvents             *bool   `flag_name:"push_events" type:"boolean" required:"no" description:"When true, the hook will fire on push events"`
+	TagPushEvents          *bool   `flag_name:"tag_push_events" type:"boolean" required:"no" description:"When true, the hook will fire on new tags being pushed"`
+	MergeRequestsEvents    *bool   `flag_name:"merge_requests_events" type:"boolean" required:"no" description:"Trigger hook on merge requests events"`
+	RepositoryUpdateEvents *bool   `flag_name:"repository_update_events" type:"boolean" required:"no" description:"Trigger hook on repository update events"`
+	EnableSSLVerification  *bool   `flag_name:"enable_ssl_verification" type:"boolean" required:"no" description:"Do SSL verification when triggering the hook"`
+}
+
+var systemHooksAddCmd = &golabCommand{
+	Parent: systemHooksCmd.Cmd,
+	Flags:  &systemHooksAddFlags{},
+	Opts:   &addSystemHookOptions{},
+	Cmd: &cobra.Command{
+		Use:   "add",
+		Short: "Add new system hook",
+		Long:  `Add a new system hook.`,
+	},
+	Run: func(cmd golabCommand) error {
+		opts := cmd.Opts.(*addSystemHookOptions)
+		hook := &systemHook{}
+		_, err := gitlabRequest("POST", "hooks", opts, hook)
+		if err != nil {
+			return err
+		}
+		return OutputJson(hook)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/system_hooks.html#test-system-hook
+type systemHooksTestFlags struct {
+	Id *int `flag_name:"id" short:"i" type:"integer" required:"yes" description:"The ID of the hook"`
+}
+
+// systemHookTest is the result of testing a system hook, APIStatus is the
+// status of Gitlab's API, the response of the hook endpoint is not available
+type systemHookTest struct {
+	APIStatus int         `json:"api_status"`
+	Event     interface{} `json:"event"`
+}
+
+var systemHooksTestCmd = &golabCommand{
+	Parent: systemHooksCmd.Cmd,
+	Flags:  &systemHooksTestFlags{},
+	Cmd: &cobra.Command{
+		Use:   "test",
+		Short: "Test system hook",
+		Long: `Let Gitlab send a project_create test event to the hook and print the HTTP status of Gitlab's API (api_status) together with the sent event.
+
+Gitlab delivers the event synchronously, but does not return the response of the hook endpoint - api_status is not the status of the hook endpoint and failed deliveries are only shown in the Gitlab logs.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*systemHooksTestFlags)
+		result := &systemHookTest{}
+		resp, err := gitlabRequest("POST", "hooks/"+strconv.Itoa(*flags.Id), nil, &result.Event)
+		if err != nil {
+			return err
+		}
+		result.APIStatus = resp.StatusCode
+		return OutputJson(result)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/system_hooks.html#delete-system-hook
+type systemHooksDeleteFlags struct {
+	Id *int `flag_name:"id" short:"i" type:"integer" required:"yes" description:"The ID of the hook"`
+}
+
+var systemHooksDeleteCmd = &golabCommand{
+	Parent: systemHooksCmd.Cmd,
+	Flags:  &systemHooksDeleteFlags{},
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Delete system hook",
+		Long:  `Deletes a system hook.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*systemHooksDeleteFlags)
+		_, err := gitlabClient.SystemHooks.DeleteHook(*flags.Id)
+		return err
+	},
+}
+
+func init() {
+	systemHooksCmd.Init()
+	systemHooksListCmd.Init()
+	systemHooksAddCmd.Init()
+	systemHooksTestCmd.Init()
+	systemHooksDeleteCmd.Init()
+	AddDefaultColumns(systemHook{}, "id", "url", "push_events", "tag_push_events", "merge_requests_events", "repository_update_events")
+}
@@ -0,0 +1,85 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("admin system-hooks command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `add` sub command is executed", func() {
+		It("sends the token and event toggles", func() {
+			var body map[string]interface{}
+			mux.HandleFunc("/api/v4/hooks", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal("POST"))
+				json.NewDecoder(r.Body).Decode(&body)
+				fmt.Fprint(w, `{"id":1,"url":"https://audit.example.com","merge_requests_events":true}`)
+			})
+			_, _, err := executeCommand(RootCmd, "admin", "system-hooks", "add", "-u", "https://audit.example.com", "-t", "secret",
+				"--merge_requests_events", "--push_events=false")
+			Expect(err).To(BeNil())
+			Expect(body).To(Equal(map[string]interface{}{
+				"url":                   "https://audit.example.com",
+				"token":                 "secret",
+				"merge_requests_events": true,
+				"push_events":           false,
+			}))
+		})
+	})
+
+	Context("when the `test` sub command is executed", func() {
+		It("prints the API status and the test event", func() {
+			mux.HandleFunc("/api/v4/hooks/1", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal("POST"))
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"event_name":"project_create","name":"Ruby"}`)
+			})
+			out, _, err := executeCommand(RootCmd, "admin", "system-hooks", "test", "-i", "1")
+			Expect(err).To(BeNil())
+			Expect(out).To(ContainSubstring(`"api_status": 201`))
+			Expect(out).To(ContainSubstring(`"event_name": "project_create"`))
+		})
+	})
+})
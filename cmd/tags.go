@@ -0,0 +1,344 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// see https://docs.gitlab.com/ce/api/tags.html
+var tagsCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "tags",
+		Aliases: []string{"tag"},
+		Short:   "Manage repository tags",
+		Long:    `Show, create and delete repository tags and manage the release notes attached to them`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/tags.html#list-project-repository-tags
+type tagsListFlags struct {
+	Id *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+}
+
+var tagsListCmd = &golabCommand{
+	Parent: tagsCmd.Cmd,
+	Flags:  &tagsListFlags{},
+	Opts:   &gitlab.ListTagsOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List project repository tags",
+		Long:  `Get a list of repository tags from a project, sorted by name in reverse alphabetical order. This endpoint can be accessed without authentication if the repository is publicly accessible.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*tagsListFlags)
+		opts := cmd.Opts.(*gitlab.ListTagsOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			return gitlabClient.Tags.ListTags(*flags.Id, opts)
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/tags.html#get-a-single-repository-tag
+type tagsGetFlags struct {
+	Id      *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	TagName *string `flag_name:"tag_name" short:"t" type:"string" required:"yes" description:"The name of the tag"`
+}
+
+var tagsGetCmd = &golabCommand{
+	Parent: tagsCmd.Cmd,
+	Flags:  &tagsGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get a single repository tag",
+		Long:  `Get a specific repository tag determined by its name. This endpoint can be accessed without authentication if the repository is publicly accessible.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*tagsGetFlags)
+		tag, _, err := gitlabClient.Tags.GetTag(*flags.Id, url.PathEscape(*flags.TagName))
+		if err != nil {
+			return err
+		}
+		return OutputJson(tag)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/tags.html#create-a-new-tag
+type tagsCreateFlags struct {
+	Id                     *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	TagName                *string `flag_name:"tag_name" short:"t" type:"string" required:"yes" description:"The name of a tag"`
+	Ref                    *string `flag_name:"ref" short:"r" type:"string" required:"yes" description:"Create tag using commit SHA, another tag name, or branch name"`
+	Message                *string `flag_name:"message" short:"m" type:"string" required:"no" description:"Creates annotated tag"`
+	ReleaseDescription     *string `flag_name:"release_description" short:"d" type:"string" required:"no" description:"Add release notes to the git tag and store it in the GitLab database"`
+	ReleaseDescriptionFile *string `flag_name:"release_description_file" short:"f" type:"string" required:"no" description:"File to read the release notes from, '-' reads from stdin"`
+	Changelog              *bool   `flag_name:"changelog" short:"c" type:"boolean" required:"no" description:"Add the titles of the merge requests merged since the previous tag to the release notes"`
+	PreviousTag            *string `flag_name:"previous_tag" short:"p" type:"string" required:"no" description:"Tag to build the changelog from (default: the most recently updated tag)"`
+}
+
+var tagsCreateCmd = &golabCommand{
+	Parent: tagsCmd.Cmd,
+	Flags:  &tagsCreateFlags{},
+	Opts:   &gitlab.CreateTagOptions{},
+	Cmd: &cobra.Command{
+		Use:   "create",
+		Short: "Create a new tag",
+		Long: `Creates a new tag in the repository that points to the supplied ref.
+
+With --changelog, the titles of all merge requests that were merged between the previous tag and the ref are added to the release notes.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*tagsCreateFlags)
+		opts := cmd.Opts.(*gitlab.CreateTagOptions)
+		if flags.ReleaseDescriptionFile != nil {
+			description, err := contentFromFlags(flags.ReleaseDescription, flags.ReleaseDescriptionFile, "release_description", "release_description_file")
+			if err != nil {
+				return err
+			}
+			opts.ReleaseDescription = &description
+		}
+		if flags.Changelog != nil && *flags.Changelog {
+			changelog, err := buildChangelog(*flags.Id, flags.PreviousTag, *flags.Ref)
+			if err != nil {
+				return err
+			}
+			if opts.ReleaseDescription != nil && *opts.ReleaseDescription != "" {
+				changelog = *opts.ReleaseDescription + "\n\n" + changelog
+			}
+			opts.ReleaseDescription = &changelog
+		}
+		tag, _, err := gitlabClient.Tags.CreateTag(*flags.Id, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(tag)
+	},
+}
+
+// buildChangelog lists the titles of the merge requests that were merged
+// between the previous tag and ref
+func buildChangelog(pid string, previousTag *string, ref string) (string, error) {
+	var from *gitlab.Tag
+	var err error
+	if previousTag != nil {
+		from, _, err = gitlabClient.Tags.GetTag(pid, url.PathEscape(*previousTag))
+	} else {
+		from, err = latestTag(pid)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	commits := map[string]bool{}
+	if from == nil {
+		// no previous tag - all merge requests up to ref are part of the changelog
+		opts := &gitlab.ListCommitsOptions{RefName: &ref}
+		err = forEachPage(&opts.ListOptions, func() (*gitlab.Response, error) {
+			page, resp, err := gitlabClient.Commits.ListCommits(pid, opts)
+			for _, commit := range page {
+				commits[commit.ID] = true
+			}
+			return resp, err
+		})
+	} else {
+		var compare *gitlab.Compare
+		compare, _, err = gitlabClient.Repositories.Compare(pid, &gitlab.CompareOptions{From: &from.Name, To: &ref})
+		if compare != nil {
+			for _, commit := range compare.Commits {
+				commits[commit.ID] = true
+			}
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	opts := &gitlab.ListProjectMergeRequestsOptions{State: gitlab.String("merged"), OrderBy: gitlab.String("updated_at")}
+	err = forEachPage(&opts.ListOptions, func() (*gitlab.Response, error) {
+		mrs, resp, err := gitlabClient.MergeRequests.ListProjectMergeRequests(pid, opts)
+		for _, mr := range mrs {
+			if from != nil && from.Commit != nil && from.Commit.CommittedDate != nil && mr.UpdatedAt != nil && mr.UpdatedAt.Before(*from.Commit.CommittedDate) {
+				// merge requests are ordered by update, all further ones were merged before the previous tag
+				return nil, err
+			}
+			if commits[mr.MergeCommitSHA] || commits[mr.SHA] {
+				lines = append(lines, fmt.Sprintf("* %s (!%d)", mr.Title, mr.IID))
+			}
+		}
+		return resp, err
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(lines) == 0 {
+		return "No merged merge requests.", nil
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+type listTagsOptions struct {
+	gitlab.ListOptions
+	OrderBy *string `url:"order_by,omitempty" json:"order_by,omitempty"`
+	Sort    *string `url:"sort,omitempty" json:"sort,omitempty"`
+}
+
+// latestTag returns the most recently updated tag of a project or nil, if
+// the project has no tags
+func latestTag(pid string) (*gitlab.Tag, error) {
+	// the vendored ListTagsOptions cannot order tags, by default they are
+	// ordered by name, which would pick v1.9 over v1.10
+	opts := &listTagsOptions{ListOptions: gitlab.ListOptions{PerPage: 1}, OrderBy: gitlab.String("updated"), Sort: gitlab.String("desc")}
+	var tags []*gitlab.Tag
+	_, err := gitlabRequest("GET", projectPath(pid, "/repository/tags"), opts, &tags)
+	if err != nil || len(tags) == 0 {
+		return nil, err
+	}
+	return tags[0], nil
+}
+
+// see https://docs.gitlab.com/ce/api/tags.html#delete-a-tag
+type tagsDeleteFlags struct {
+	Id      *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	TagName *string `flag_name:"tag_name" short:"t" type:"string" required:"yes" description:"The name of a tag"`
+}
+
+var tagsDeleteCmd = &golabCommand{
+	Parent: tagsCmd.Cmd,
+	Flags:  &tagsDeleteFlags{},
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a tag",
+		Long:  `Deletes a tag of a repository with given name.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*tagsDeleteFlags)
+		_, err := gitlabClient.Tags.DeleteTag(*flags.Id, url.PathEscape(*flags.TagName))
+		return err
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/tags.html#create-a-new-release
+var tagsReleaseCmd = &golabCommand{
+	Parent: tagsCmd.Cmd,
+	Cmd: &cobra.Command{
+		Use:   "release",
+		Short: "Manage release notes",
+		Long:  `Create and edit the release notes attached to a tag`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// release represents the release notes of a tag, the vendored TagsService
+// does not support releases
+type release struct {
+	TagName     string `json:"tag_name"`
+	Description string `json:"description"`
+}
+
+// releaseOptions represents the options of the create and update release APIs
+type releaseOptions struct {
+	Description *string `url:"description,omitempty" json:"description,omitempty"`
+}
+
+// see https://docs.gitlab.com/ce/api/tags.html#create-a-new-release
+type tagsReleaseCreateFlags struct {
+	Id              *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	TagName         *string `flag_name:"tag_name" short:"t" type:"string" required:"yes" description:"The name of a tag"`
+	Description     *string `flag_name:"description" short:"d" type:"string" required:"no" description:"Release notes with markdown support"`
+	DescriptionFile *string `flag_name:"description_file" short:"f" type:"string" required:"no" description:"File to read the release notes from, '-' reads from stdin"`
+}
+
+var tagsReleaseCreateCmd = &golabCommand{
+	Parent: tagsReleaseCmd.Cmd,
+	Flags:  &tagsReleaseCreateFlags{},
+	Cmd: &cobra.Command{
+		Use:   "create",
+		Short: "Create a new release",
+		Long:  `Add release notes to the existing git tag. If there already exists a release for the given tag, status code 409 is returned.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*tagsReleaseCreateFlags)
+		return saveRelease("POST", *flags.Id, *flags.TagName, flags.Description, flags.DescriptionFile)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/tags.html#update-a-release
+type tagsReleaseEditFlags struct {
+	Id              *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	TagName         *string `flag_name:"tag_name" short:"t" type:"string" required:"yes" description:"The name of a tag"`
+	Description     *string `flag_name:"description" short:"d" type:"string" required:"no" description:"Release notes with markdown support"`
+	DescriptionFile *string `flag_name:"description_file" short:"f" type:"string" required:"no" description:"File to read the release notes from, '-' reads from stdin"`
+}
+
+var tagsReleaseEditCmd = &golabCommand{
+	Parent: tagsReleaseCmd.Cmd,
+	Flags:  &tagsReleaseEditFlags{},
+	Cmd: &cobra.Command{
+		Use:   "edit",
+		Short: "Update a release",
+		Long:  `Updates the release notes of a given release.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*tagsReleaseEditFlags)
+		return saveRelease("PUT", *flags.Id, *flags.TagName, flags.Description, flags.DescriptionFile)
+	},
+}
+
+func saveRelease(method string, pid string, tagName string, description, descriptionFile *string) error {
+	notes, err := contentFromFlags(description, descriptionFile, "description", "description_file")
+	if err != nil {
+		return err
+	}
+	r := new(release)
+	_, err = gitlabRequest(method, projectPath(pid, "/repository/tags/%s/release", url.PathEscape(tagName)), &releaseOptions{Description: &notes}, r)
+	if err != nil {
+		return err
+	}
+	return OutputJson(r)
+}
+
+func init() {
+	tagsCmd.Init()
+	tagsListCmd.Init()
+	tagsGetCmd.Init()
+	tagsCreateCmd.Init()
+	tagsDeleteCmd.Init()
+	tagsReleaseCmd.Init()
+	tagsReleaseCreateCmd.Init()
+	tagsReleaseEditCmd.Init()
+	AddDefaultColumns(gitlab.Tag{}, "name", "commit.short_id", "message")
+	AddDefaultColumns(release{}, "tag_name", "description")
+}
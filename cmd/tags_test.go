@@ -0,0 +1,95 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("buildChangelog", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("lists the merge requests merged since the previous tag", func() {
+		from := ""
+		mux.HandleFunc("/api/v4/projects/1/repository/tags", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[{"name":"v1.0","commit":{"id":"aaa","committed_date":"2018-01-10T10:00:00Z"}}]`)
+		})
+		mux.HandleFunc("/api/v4/projects/1/repository/compare", func(w http.ResponseWriter, r *http.Request) {
+			from = r.URL.Query().Get("from")
+			fmt.Fprint(w, `{"commits":[{"id":"bbb"},{"id":"ccc"}]}`)
+		})
+		mux.HandleFunc("/api/v4/projects/1/merge_requests", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[
+				{"iid":3,"title":"Add feature","merge_commit_sha":"ccc","updated_at":"2018-01-12T10:00:00Z"},
+				{"iid":2,"title":"Fast-forward fix","sha":"bbb","updated_at":"2018-01-11T10:00:00Z"},
+				{"iid":1,"title":"Old change","merge_commit_sha":"aaa","updated_at":"2018-01-09T10:00:00Z"}
+			]`)
+		})
+		changelog, err := buildChangelog("1", nil, "master")
+		Expect(err).To(BeNil())
+		Expect(from).To(Equal("v1.0"))
+		Expect(changelog).To(Equal("* Add feature (!3)\n* Fast-forward fix (!2)"))
+	})
+
+	It("builds the changelog from the most recently updated tag", func() {
+		from := ""
+		mux.HandleFunc("/api/v4/projects/1/repository/tags", func(w http.ResponseWriter, r *http.Request) {
+			v19 := `{"name":"v1.9","commit":{"id":"aaa","committed_date":"2018-01-10T10:00:00Z"}}`
+			v110 := `{"name":"v1.10","commit":{"id":"bbb","committed_date":"2018-02-10T10:00:00Z"}}`
+			if r.URL.Query().Get("order_by") == "updated" && r.URL.Query().Get("sort") == "desc" {
+				fmt.Fprintf(w, "[%s,%s]", v110, v19)
+			} else {
+				fmt.Fprintf(w, "[%s,%s]", v19, v110)
+			}
+		})
+		mux.HandleFunc("/api/v4/projects/1/repository/compare", func(w http.ResponseWriter, r *http.Request) {
+			from = r.URL.Query().Get("from")
+			fmt.Fprint(w, `{"commits":[]}`)
+		})
+		mux.HandleFunc("/api/v4/projects/1/merge_requests", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `[]`)
+		})
+		_, err := buildChangelog("1", nil, "master")
+		Expect(err).To(BeNil())
+		Expect(from).To(Equal("v1.10"))
+	})
+})
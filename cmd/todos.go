@@ -0,0 +1,231 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// listTodosOptions adds pagination and the group filter to the vendored
+// ListTodosOptions
+type listTodosOptions struct {
+	gitlab.ListOptions
+	Action    *string `url:"action,omitempty" json:"action,omitempty"`
+	AuthorID  *int    `url:"author_id,omitempty" json:"author_id,omitempty"`
+	ProjectID *int    `url:"project_id,omitempty" json:"project_id,omitempty"`
+	GroupID   *int    `url:"group_id,omitempty" json:"group_id,omitempty"`
+	State     *string `url:"state,omitempty" json:"state,omitempty"`
+	Type      *string `url:"type,omitempty" json:"type,omitempty"`
+}
+
+// see https://docs.gitlab.com/ce/api/todos.html
+var todosCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "todos",
+		Aliases: []string{"todo"},
+		Short:   "Manage todos",
+		Long:    `List the todos of the authenticated user and mark them as done`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/todos.html#get-a-list-of-todos
+type todosListFlags struct {
+	Action    *string `flag_name:"action" short:"a" type:"string" required:"no" description:"The action to be filtered. Can be assigned, mentioned, build_failed, marked, approval_required or directly_addressed"`
+	AuthorID  *int    `flag_name:"author_id" type:"integer" required:"no" description:"The ID of an author"`
+	ProjectID *int    `flag_name:"project_id" short:"p" type:"integer" required:"no" description:"The ID of a project"`
+	GroupID   *int    `flag_name:"group_id" short:"g" type:"integer" required:"no" description:"The ID of a group"`
+	State     *string `flag_name:"state" short:"s" type:"string" required:"no" description:"The state of the todo. Can be either pending or done"`
+	Type      *string `flag_name:"type" short:"t" type:"string" required:"no" description:"The type of a todo. Can be either Issue or MergeRequest"`
+}
+
+var todosListCmd = &golabCommand{
+	Parent: todosCmd.Cmd,
+	Flags:  &todosListFlags{},
+	Opts:   &listTodosOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "Get a list of todos",
+		Long:  `Returns a list of todos. When no filter is applied, it returns all pending todos for the current user.`,
+	},
+	Run: func(cmd golabCommand) error {
+		opts := cmd.Opts.(*listTodosOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			var todos []*gitlab.Todo
+			resp, err := gitlabRequest("GET", "todos", opts, &todos)
+			return todos, resp, err
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/todos.html#mark-a-todo-as-done
+type todosDoneFlags struct {
+	All       *bool   `flag_name:"all" type:"boolean" required:"no" description:"Mark all pending todos as done"`
+	Where     *string `flag_name:"where" short:"w" type:"string" required:"no" description:"Only mark todos as done whose fields match, e.g. target.state=merged; conditions are separated by comma, != negates a condition"`
+	Action    *string `flag_name:"action" short:"a" type:"string" required:"no" description:"Only mark todos with this action as done. Can be assigned, mentioned, build_failed, marked, approval_required or directly_addressed"`
+	AuthorID  *int    `flag_name:"author_id" type:"integer" required:"no" description:"Only mark todos of this author as done"`
+	ProjectID *int    `flag_name:"project_id" short:"p" type:"integer" required:"no" description:"Only mark todos of this project as done"`
+	GroupID   *int    `flag_name:"group_id" short:"g" type:"integer" required:"no" description:"Only mark todos of this group as done"`
+	Type      *string `flag_name:"type" short:"t" type:"string" required:"no" description:"Only mark todos of this type as done. Can be either Issue or MergeRequest"`
+	DryRun    *bool   `flag_name:"dry_run" type:"boolean" required:"no" description:"Only print the todos that would be marked as done"`
+}
+
+var todosDoneCmd = &golabCommand{
+	Parent: todosCmd.Cmd,
+	Flags:  &todosDoneFlags{},
+	Opts:   &listTodosOptions{},
+	Cmd: &cobra.Command{
+		Use:   "done [id...]",
+		Short: "Mark todos as done",
+		Long: `Mark the todos with the given IDs, all pending todos (--all) or all pending todos matching a filter as done.
+
+Filters are the flags of 'todos ls' and --where, which matches fields of the todos like they are shown by 'todos ls'.
+
+Example: mark all todos on merged merge requests as done
+
+    golab todos done -t MergeRequest --where target.state=merged`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*todosDoneFlags)
+		opts := cmd.Opts.(*listTodosOptions)
+		filtered := flags.Where != nil || opts.Action != nil || opts.AuthorID != nil || opts.ProjectID != nil || opts.GroupID != nil || opts.Type != nil
+		all := flags.All != nil && *flags.All
+		dryRun := flags.DryRun != nil && *flags.DryRun
+		switch {
+		case len(cmd.Args) > 0 && (all || filtered), all && filtered:
+			return errors.New("only one of todo IDs, --all or filters can be given")
+		case len(cmd.Args) > 0:
+			return markTodosDone(cmd.Args, dryRun)
+		case all && !dryRun:
+			_, err := gitlabClient.Todos.MarkAllTodosAsDone()
+			if err == nil {
+				fmt.Println("marked all todos as done")
+			}
+			return err
+		case all || filtered:
+			var conditions []string
+			if flags.Where != nil {
+				conditions = strings.Split(*flags.Where, ",")
+			}
+			return markMatchingTodosDone(opts, conditions, dryRun)
+		}
+		return errors.New("todo IDs, --all or a filter are required")
+	},
+}
+
+func markTodosDone(ids []string, dryRun bool) error {
+	for _, arg := range ids {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("invalid todo ID %s", arg)
+		}
+		if !dryRun {
+			if _, err := gitlabClient.Todos.MarkTodoAsDone(id); err != nil {
+				return err
+			}
+		}
+		printTodoDone(dryRun, id, "")
+	}
+	return nil
+}
+
+// markMatchingTodosDone marks all pending todos that match the filters in
+// opts and all conditions as done. The conditions are checked for all todos
+// before the first one is marked, so an invalid condition marks nothing.
+func markMatchingTodosDone(opts *listTodosOptions, conditions []string, dryRun bool) error {
+	opts.State = gitlab.String("pending")
+	var todos []*gitlab.Todo
+	err := forEachPage(&opts.ListOptions, func() (*gitlab.Response, error) {
+		var page []*gitlab.Todo
+		resp, err := gitlabRequest("GET", "todos", opts, &page)
+		todos = append(todos, page...)
+		return resp, err
+	})
+	if err != nil {
+		return err
+	}
+	var matching []*gitlab.Todo
+	for _, todo := range todos {
+		matches, err := matchesConditions(todo, conditions)
+		if err != nil {
+			return err
+		}
+		if matches {
+			matching = append(matching, todo)
+		}
+	}
+	for _, todo := range matching {
+		if !dryRun {
+			if _, err := gitlabClient.Todos.MarkTodoAsDone(todo.ID); err != nil {
+				return err
+			}
+		}
+		printTodoDone(dryRun, todo.ID, " ("+todo.TargetType+" "+todo.Target.Title+")")
+	}
+	return nil
+}
+
+func printTodoDone(dryRun bool, id int, details string) {
+	action := "marked"
+	if dryRun {
+		action = "would mark"
+	}
+	fmt.Printf("%s todo #%d as done%s\n", action, id, details)
+}
+
+// matchesConditions returns true if object matches all conditions of the
+// form field=value or field!=value, fields are given like in --columns
+func matchesConditions(object interface{}, conditions []string) (bool, error) {
+	for _, condition := range conditions {
+		negate := strings.Contains(condition, "!=")
+		parts := strings.SplitN(strings.Replace(condition, "!=", "=", 1), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return false, fmt.Errorf("invalid condition %s, expected field=value or field!=value", condition)
+		}
+		value, err := FieldValue(object, strings.TrimSpace(parts[0]))
+		if err != nil {
+			return false, err
+		}
+		if (value == strings.TrimSpace(parts[1])) == negate {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func init() {
+	AddDefaultColumns(gitlab.Todo{}, "id", "action_name", "target_type", "state", "project.path_with_namespace")
+	todosCmd.Init()
+	todosListCmd.Init()
+	todosDoneCmd.Init()
+}
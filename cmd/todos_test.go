@@ -0,0 +1,98 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("todos command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `done` sub command is executed with `--where`", func() {
+		It("marks the pending todos matching the filter as done", func() {
+			var query string
+			var marked []string
+			mux.HandleFunc("/api/v4/todos", func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.RawQuery
+				fmt.Fprint(w, `[
+					{"id":1,"target_type":"MergeRequest","target":{"title":"Merged","state":"merged"}},
+					{"id":2,"target_type":"MergeRequest","target":{"title":"Open","state":"opened"}}
+				]`)
+			})
+			mux.HandleFunc("/api/v4/todos/", func(w http.ResponseWriter, r *http.Request) {
+				marked = append(marked, r.Method+" "+r.URL.Path)
+			})
+
+			out, _, err := executeCommand(RootCmd, "todos", "done", "-t", "MergeRequest", "--where", "target.state=merged")
+			Expect(err).To(BeNil())
+			Expect(query).To(ContainSubstring("state=pending"))
+			Expect(query).To(ContainSubstring("type=MergeRequest"))
+			Expect(marked).To(Equal([]string{"POST /api/v4/todos/1/mark_as_done"}))
+			Expect(out).To(Equal("marked todo #1 as done (MergeRequest Merged)"))
+		})
+
+		It("marks nothing if a condition uses an unknown field", func() {
+			var marked []string
+			mux.HandleFunc("/api/v4/todos", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"id":1,"target_type":"MergeRequest","target":{"title":"Open","state":"opened"}}]`)
+			})
+			mux.HandleFunc("/api/v4/todos/", func(w http.ResponseWriter, r *http.Request) {
+				marked = append(marked, r.Method+" "+r.URL.Path)
+			})
+
+			_, _, err := executeCommand(RootCmd, "todos", "done", "-t", "MergeRequest", "--where", "target.stat!=opened")
+			Expect(err).To(MatchError("unknown field target.stat"))
+			Expect(marked).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("matchesConditions", func() {
+	It("supports negated conditions", func() {
+		todo := &gitlab.Todo{ActionName: gitlab.TodoMentioned}
+		Expect(matchesConditions(todo, []string{"action_name!=assigned"})).To(BeTrue())
+		Expect(matchesConditions(todo, []string{"action_name=assigned"})).To(BeFalse())
+		_, err := matchesConditions(todo, []string{"action_name"})
+		Expect(err).NotTo(BeNil())
+	})
+})
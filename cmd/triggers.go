@@ -0,0 +1,288 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// see https://docs.gitlab.com/ce/api/pipeline_triggers.html
+var triggersCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "triggers",
+		Aliases: []string{"trigger"},
+		Short:   "Manage pipeline triggers",
+		Long:    `Manage the trigger tokens of a project and run pipelines with them`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipeline_triggers.html#list-project-triggers
+type triggersListFlags struct {
+	Id *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+}
+
+var triggersListCmd = &golabCommand{
+	Parent: triggersCmd.Cmd,
+	Flags:  &triggersListFlags{},
+	Opts:   &gitlab.ListPipelineTriggersOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List project triggers",
+		Long:  `Get a list of project's build triggers.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*triggersListFlags)
+		opts := cmd.Opts.(*gitlab.ListPipelineTriggersOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			return gitlabClient.PipelineTriggers.ListPipelineTriggers(*flags.Id, opts)
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipeline_triggers.html#get-trigger-details
+type triggersGetFlags struct {
+	Id        *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	TriggerId *int    `flag_name:"trigger_id" short:"t" type:"integer" required:"yes" description:"The trigger id"`
+}
+
+var triggersGetCmd = &golabCommand{
+	Parent: triggersCmd.Cmd,
+	Flags:  &triggersGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get trigger details",
+		Long:  `Get details of project's build trigger.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*triggersGetFlags)
+		trigger, _, err := gitlabClient.PipelineTriggers.GetPipelineTrigger(*flags.Id, *flags.TriggerId)
+		if err != nil {
+			return err
+		}
+		return OutputJson(trigger)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipeline_triggers.html#create-a-project-trigger
+type triggersCreateFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Description *string `flag_name:"description" short:"d" type:"string" required:"yes" description:"The trigger name"`
+}
+
+var triggersCreateCmd = &golabCommand{
+	Parent: triggersCmd.Cmd,
+	Flags:  &triggersCreateFlags{},
+	Opts:   &gitlab.AddPipelineTriggerOptions{},
+	Cmd: &cobra.Command{
+		Use:   "create",
+		Short: "Create a project trigger",
+		Long:  `Create a trigger for a project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*triggersCreateFlags)
+		opts := cmd.Opts.(*gitlab.AddPipelineTriggerOptions)
+		trigger, _, err := gitlabClient.PipelineTriggers.AddPipelineTrigger(*flags.Id, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(trigger)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipeline_triggers.html#update-a-project-trigger
+type triggersEditFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	TriggerId   *int    `flag_name:"trigger_id" short:"t" type:"integer" required:"yes" description:"The trigger id"`
+	Description *string `flag_name:"description" short:"d" type:"string" required:"no" description:"The trigger name"`
+}
+
+var triggersEditCmd = &golabCommand{
+	Parent: triggersCmd.Cmd,
+	Flags:  &triggersEditFlags{},
+	Opts:   &gitlab.EditPipelineTriggerOptions{},
+	Cmd: &cobra.Command{
+		Use:   "edit",
+		Short: "Update a project trigger",
+		Long:  `Update a trigger for a project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*triggersEditFlags)
+		opts := cmd.Opts.(*gitlab.EditPipelineTriggerOptions)
+		trigger, _, err := gitlabClient.PipelineTriggers.EditPipelineTrigger(*flags.Id, *flags.TriggerId, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(trigger)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipeline_triggers.html#take-ownership-of-a-project-trigger
+type triggersTakeOwnershipFlags struct {
+	Id        *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	TriggerId *int    `flag_name:"trigger_id" short:"t" type:"integer" required:"yes" description:"The trigger id"`
+}
+
+var triggersTakeOwnershipCmd = &golabCommand{
+	Parent: triggersCmd.Cmd,
+	Flags:  &triggersTakeOwnershipFlags{},
+	Cmd: &cobra.Command{
+		Use:   "take-ownership",
+		Short: "Take ownership of a project trigger",
+		Long:  `Take ownership of a trigger, triggered pipelines then run as the authenticated user.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*triggersTakeOwnershipFlags)
+		trigger, _, err := gitlabClient.PipelineTriggers.TakeOwnershipOfPipelineTrigger(*flags.Id, *flags.TriggerId)
+		if err != nil {
+			return err
+		}
+		return OutputJson(trigger)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/pipeline_triggers.html#remove-a-project-trigger
+type triggersDeleteFlags struct {
+	Id        *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	TriggerId *int    `flag_name:"trigger_id" short:"t" type:"integer" required:"yes" description:"The trigger id"`
+}
+
+var triggersDeleteCmd = &golabCommand{
+	Parent: triggersCmd.Cmd,
+	Flags:  &triggersDeleteFlags{},
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Remove a project trigger",
+		Long:  `Remove a project's build trigger.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*triggersDeleteFlags)
+		_, err := gitlabClient.PipelineTriggers.DeletePipelineTrigger(*flags.Id, *flags.TriggerId)
+		return err
+	},
+}
+
+// see https://docs.gitlab.com/ce/ci/triggers/README.html#triggering-a-pipeline
+type triggersRunFlags struct {
+	Id            *string   `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project"`
+	Ref           *string   `flag_name:"ref" short:"r" type:"string" required:"yes" description:"The branch or tag to run the pipeline on"`
+	Token         *string   `flag_name:"token" type:"string" required:"yes" description:"The trigger token"`
+	Vars          *[]string `flag_name:"var" short:"v" type:"array" required:"no" description:"Variable passed to the pipeline as KEY=VALUE, can be given multiple times"`
+	VariablesFile *string   `flag_name:"variables_file" short:"f" type:"string" required:"no" description:"Dotenv file with variables passed to the pipeline, '-' reads from stdin; --var takes precedence"`
+	Watch         *bool     `flag_name:"watch" short:"w" type:"boolean" required:"no" description:"Watch the pipeline until it finishes, exits with an error if it fails"`
+	Interval      *int      `flag_name:"interval" type:"integer" required:"no" description:"Seconds to wait between two status requests when watching (default: 5)"`
+	Timeout       *int      `flag_name:"timeout" type:"integer" required:"no" description:"Seconds after which watching is aborted with an error (default: no timeout)"`
+}
+
+var triggersRunCmd = &golabCommand{
+	Parent: triggersCmd.Cmd,
+	Flags:  &triggersRunFlags{},
+	Opts:   &gitlab.RunPipelineTriggerOptions{},
+	Cmd: &cobra.Command{
+		Use:   "run",
+		Short: "Run a pipeline with a trigger token",
+		Long: `Trigger a pipeline for a branch or tag and print the created pipeline.
+
+Example:
+
+    golab triggers run -i my-group/deployment --token $TOKEN -r master -v ENVIRONMENT=staging -v VERSION=1.2.3 --watch`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*triggersRunFlags)
+		opts := cmd.Opts.(*gitlab.RunPipelineTriggerOptions)
+		variables, err := triggerVariables(flags.Vars, flags.VariablesFile)
+		if err != nil {
+			return err
+		}
+		opts.Variables = variables
+		pipeline, _, err := gitlabClient.PipelineTriggers.RunPipelineTrigger(*flags.Id, opts)
+		if err != nil {
+			return err
+		}
+		if err := OutputJson(pipeline); err != nil {
+			return err
+		}
+		if flags.Watch == nil || !*flags.Watch {
+			return nil
+		}
+		interval := 5 * time.Second
+		if flags.Interval != nil && *flags.Interval > 0 {
+			interval = time.Duration(*flags.Interval) * time.Second
+		}
+		var timeout time.Duration
+		if flags.Timeout != nil {
+			timeout = time.Duration(*flags.Timeout) * time.Second
+		}
+		return watchPipeline(*flags.Id, pipeline.ID, interval, timeout)
+	},
+}
+
+// triggerVariables merges the variables read from a dotenv file with the
+// KEY=VALUE pairs given with --var
+func triggerVariables(vars *[]string, file *string) (map[string]string, error) {
+	variables := map[string]string{}
+	if file != nil {
+		content, err := readFileOrStdin(*file)
+		if err != nil {
+			return nil, err
+		}
+		fileVars, err := parseDotenv(content)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range fileVars {
+			variables[*v.Key] = *v.Value
+		}
+	}
+	if vars != nil {
+		for _, v := range *vars {
+			parts := strings.SplitN(v, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return nil, errors.New("invalid variable " + v + ", expected KEY=VALUE")
+			}
+			variables[parts[0]] = parts[1]
+		}
+	}
+	return variables, nil
+}
+
+func init() {
+	AddDefaultColumns(gitlab.PipelineTrigger{}, "id", "description", "owner.username", "last_used")
+	triggersCmd.Init()
+	triggersListCmd.Init()
+	triggersGetCmd.Init()
+	triggersCreateCmd.Init()
+	triggersEditCmd.Init()
+	triggersTakeOwnershipCmd.Init()
+	triggersDeleteCmd.Init()
+	triggersRunCmd.Init()
+}
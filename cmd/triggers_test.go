@@ -0,0 +1,85 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("triggers command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `run` sub command is executed with variables", func() {
+		It("passes --var and file variables to the triggered pipeline", func() {
+			var body map[string]interface{}
+			mux.HandleFunc("/api/v4/projects/1/trigger/pipeline", func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&body)
+				fmt.Fprint(w, `{"id":42,"status":"pending"}`)
+			})
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			w.WriteString("ENVIRONMENT=production\nVERSION=1.0\n")
+			w.Close()
+			os.Stdin = r
+
+			_, _, err := executeCommand(RootCmd, "triggers", "run", "-i", "1", "--token", "secret", "-r", "master",
+				"-f", "-", "-v", "ENVIRONMENT=staging", "--var", "DEBUG=true")
+			Expect(err).To(BeNil())
+			Expect(body["token"]).To(Equal("secret"))
+			Expect(body["ref"]).To(Equal("master"))
+			Expect(body["variables"]).To(Equal(map[string]interface{}{
+				"ENVIRONMENT": "staging",
+				"VERSION":     "1.0",
+				"DEBUG":       "true",
+			}))
+		})
+
+		It("rejects variables without value", func() {
+			_, _, err := executeCommand(RootCmd, "triggers", "run", "-i", "1", "--token", "secret", "-r", "master", "-v", "DEBUG")
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(Equal("invalid variable DEBUG, expected KEY=VALUE"))
+		})
+	})
+})
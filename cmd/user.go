@@ -19,7 +19,6 @@
 // THE SOFTWARE.
 
 // For detailed API specification, see https://docs.gitlab.com/ce/api/users.html
-// TODO currently there is no support for GPG keys in the go-gitlab library
 
 package cmd
 
@@ -27,6 +26,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	. "github.com/michaellihs/golab/cmd/helpers"
 
@@ -142,11 +142,9 @@ var userLsCmd = &golabCommand{
 	},
 	Run: func(cmd golabCommand) error {
 		opts := cmd.Opts.(*gitlab.ListUsersOptions)
-		users, _, err := gitlabClient.Users.ListUsers(opts)
-		if err != nil {
-			return err
-		}
-		return OutputJson(users)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			return gitlabClient.Users.ListUsers(opts)
+		})
 	},
 }
 
@@ -422,6 +420,162 @@ var userSshKeysDeleteCmd = &golabCommand{
 	},
 }
 
+// gpgKey is not covered by the vendored go-gitlab, see
+// https://docs.gitlab.com/ce/api/users.html#list-all-gpg-keys
+type gpgKey struct {
+	ID        int        `json:"id"`
+	Key       string     `json:"key"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+type addGpgKeyOptions struct {
+	Key *string `url:"key,omitempty" json:"key,omitempty"`
+}
+
+// see https://docs.gitlab.com/ce/api/users.html#list-all-gpg-keys
+var userGpgKeysCmd = &golabCommand{
+	Parent: userCmd,
+	Flags:  nil,
+	Opts:   nil,
+	Cmd: &cobra.Command{
+		Use:   "gpg-keys",
+		Short: "Manage a user's GPG keys",
+		Long:  `GPG key management for users`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("command cannot be run without sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/users.html#list-all-gpg-keys
+type userGpgKeysListFlags struct {
+	UserId *string `flag_name:"user_id" short:"u" type:"string" required:"no" description:"User ID or user name of user to show GPG keys for (admin only) - if none is given, logged in user will be used"`
+}
+
+var userGpgKeysListCmd = &golabCommand{
+	Parent: userGpgKeysCmd.Cmd,
+	Flags:  &userGpgKeysListFlags{},
+	Opts:   nil,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List GPG keys",
+		Long:  `Get a list of (currently authenticated user's) GPG keys.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*userGpgKeysListFlags)
+		path, err := gpgKeysPath(flags.UserId)
+		if err != nil {
+			return err
+		}
+		var keys []*gpgKey
+		if _, err := gitlabRequest("GET", path, nil, &keys); err != nil {
+			return err
+		}
+		return OutputJson(keys)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/users.html#get-a-specific-gpg-key
+type userGpgKeysGetFlags struct {
+	KeyId  *int    `flag_name:"key_id" short:"k" required:"yes" description:"key id of GPG key to be shown"`
+	UserId *string `flag_name:"user_id" short:"u" type:"string" required:"no" description:"User ID or user name of user the GPG key belongs to - if none is given, logged in user will be used"`
+}
+
+var userGpgKeysGetCmd = &golabCommand{
+	Parent: userGpgKeysCmd.Cmd,
+	Flags:  &userGpgKeysGetFlags{},
+	Opts:   nil,
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Single GPG key",
+		Long:  `Get a specific GPG key of the currently authenticated user or of a given user.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*userGpgKeysGetFlags)
+		path, err := gpgKeysPath(flags.UserId)
+		if err != nil {
+			return err
+		}
+		key := &gpgKey{}
+		if _, err := gitlabRequest("GET", fmt.Sprintf("%s/%d", path, *flags.KeyId), nil, key); err != nil {
+			return err
+		}
+		return OutputJson(key)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/users.html#create-a-gpg-key
+type userGpgKeysAddFlags struct {
+	UserId  *string `flag_name:"user_id" short:"u" type:"string" required:"no" description:"User ID or user name of user to add GPG key for (admin only) - if none is given, logged in user will be used"`
+	Key     *string `flag_name:"key" short:"k" type:"string" required:"no" description:"The new GPG key (ASCII armored)"`
+	KeyFile *string `flag_name:"key_file" short:"f" type:"string" required:"no" description:"File to read the new GPG key from, '-' reads from stdin, e.g. 'gpg --armor --export <id> | golab user gpg-keys add -f -'"`
+}
+
+var userGpgKeysAddCmd = &golabCommand{
+	Parent: userGpgKeysCmd.Cmd,
+	Flags:  &userGpgKeysAddFlags{},
+	Cmd: &cobra.Command{
+		Use:   "add",
+		Short: "Add GPG key",
+		Long:  `Creates a new GPG key (owned by the currently authenticated user, if no user id was given)`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*userGpgKeysAddFlags)
+		content, err := contentFromFlags(flags.Key, flags.KeyFile, "key", "key_file")
+		if err != nil {
+			return err
+		}
+		path, err := gpgKeysPath(flags.UserId)
+		if err != nil {
+			return err
+		}
+		key := &gpgKey{}
+		if _, err := gitlabRequest("POST", path, &addGpgKeyOptions{Key: &content}, key); err != nil {
+			return err
+		}
+		return OutputJson(key)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/users.html#delete-a-gpg-key
+type userGpgKeysDeleteFlags struct {
+	KeyId  *int    `flag_name:"key_id" short:"k" required:"yes" description:"key id of GPG key to be deleted"`
+	UserId *string `flag_name:"user_id" short:"u" type:"string" required:"no" description:"User ID or user name of user to delete GPG key from (admin only) - if none is given, logged in user will be used"`
+}
+
+var userGpgKeysDeleteCmd = &golabCommand{
+	Parent: userGpgKeysCmd.Cmd,
+	Flags:  &userGpgKeysDeleteFlags{},
+	Opts:   nil,
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Delete GPG key",
+		Long:  `Deletes key owned by a specified user (available only for admin) or by currently logged in user.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*userGpgKeysDeleteFlags)
+		path, err := gpgKeysPath(flags.UserId)
+		if err != nil {
+			return err
+		}
+		_, err = gitlabRequest("DELETE", fmt.Sprintf("%s/%d", path, *flags.KeyId), nil, nil)
+		return err
+	},
+}
+
+// gpgKeysPath returns the API path of the GPG keys of the given user or, if
+// no user is given, of the currently authenticated user
+func gpgKeysPath(user *string) (string, error) {
+	if user == nil {
+		return "user/gpg_keys", nil
+	}
+	id, err := userIdFromFlag(*user)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("users/%d/gpg_keys", id), nil
+}
+
 // see https://docs.gitlab.com/ce/api/users.html#get-user-activities-admin-only
 type userActivitiesFlags struct {
 	From *string `flag_name:"from" transform:"string2IsoTime" type:"string" required:"no" description:"Date string in the format YEAR-MONTH-DAY, e.g. 2016-03-11. Defaults to 6 months ago."`
@@ -789,6 +943,7 @@ func getUserId(id int, username string) (int, error) {
 }
 
 func init() {
+	AddDefaultColumns(gpgKey{}, "id", "created_at")
 	userGetCmd.Init()
 	userGetAsAdminCmd.Init()
 	userLsCmd.Init()
@@ -800,6 +955,11 @@ func init() {
 	userSshKeysGetCmd.Init()
 	userSshKeysAddCmd.Init()
 	userSshKeysDeleteCmd.Init()
+	userGpgKeysCmd.Init()
+	userGpgKeysListCmd.Init()
+	userGpgKeysGetCmd.Init()
+	userGpgKeysAddCmd.Init()
+	userGpgKeysDeleteCmd.Init()
 	userActivitiesCmd.Init()
 	userImpersonationTokenCmd.Init()
 	userImpersonationTokenGetAllCmd.Init()
@@ -0,0 +1,106 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("user command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `gpg-keys add` sub command is executed", func() {
+		It("adds the key read from stdin for the current user", func() {
+			var body map[string]interface{}
+			mux.HandleFunc("/api/v4/user/gpg_keys", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal("POST"))
+				json.NewDecoder(r.Body).Decode(&body)
+				fmt.Fprint(w, `{"id":1,"key":"-----BEGIN PGP PUBLIC KEY BLOCK-----"}`)
+			})
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			w.WriteString("-----BEGIN PGP PUBLIC KEY BLOCK-----\n")
+			w.Close()
+			os.Stdin = r
+
+			out, _, err := executeCommand(RootCmd, "user", "gpg-keys", "add", "-f", "-")
+			Expect(err).To(BeNil())
+			Expect(body).To(Equal(map[string]interface{}{"key": "-----BEGIN PGP PUBLIC KEY BLOCK-----\n"}))
+			Expect(out).To(ContainSubstring(`"id": 1`))
+		})
+
+		It("fails if both --key and --key_file are given", func() {
+			_, _, err := executeCommand(RootCmd, "user", "gpg-keys", "add", "-k", "key", "-f", "key.asc")
+			Expect(err).To(MatchError("only one of --key or --key_file can be given"))
+		})
+	})
+
+	Context("when the `gpg-keys ls` sub command is executed with --user_id", func() {
+		It("lists the keys of the given user", func() {
+			mux.HandleFunc("/api/v4/users/42/gpg_keys", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `[{"id":1,"key":"abc"},{"id":2,"key":"def"}]`)
+			})
+
+			out, _, err := executeCommand(RootCmd, "user", "gpg-keys", "ls", "--user_id", "42")
+			Expect(err).To(BeNil())
+			Expect(out).To(MatchJSON(`[{"id":1,"key":"abc","created_at":null},{"id":2,"key":"def","created_at":null}]`))
+		})
+	})
+
+	Context("when the `gpg-keys delete` sub command is executed with --user_id", func() {
+		It("deletes the key of the given user", func() {
+			deleted := false
+			mux.HandleFunc("/api/v4/users/42/gpg_keys/7", func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).To(Equal("DELETE"))
+				deleted = true
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			_, _, err := executeCommand(RootCmd, "user", "gpg-keys", "delete", "-k", "7", "-u", "42")
+			Expect(err).To(BeNil())
+			Expect(deleted).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,431 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+	"gopkg.in/yaml.v2"
+)
+
+// variable is a project level CI/CD variable, the vendored BuildVariable does
+// not know about masked variables
+type variable struct {
+	Key       string `json:"key" yaml:"key"`
+	Value     string `json:"value" yaml:"value"`
+	Protected bool   `json:"protected" yaml:"protected"`
+	Masked    bool   `json:"masked" yaml:"masked"`
+}
+
+// variableOptions are used to create and update variables, attributes that
+// are nil are not changed
+type variableOptions struct {
+	Key       *string `json:"key,omitempty" yaml:"key"`
+	Value     *string `json:"value,omitempty" yaml:"value"`
+	Protected *bool   `json:"protected,omitempty" yaml:"protected"`
+	Masked    *bool   `json:"masked,omitempty" yaml:"masked"`
+}
+
+// see https://docs.gitlab.com/ce/api/project_level_variables.html
+var variablesCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "variables",
+		Aliases: []string{"variable", "vars"},
+		Short:   "Manage project CI/CD variables",
+		Long:    `Manage project level CI/CD variables, import them from and export them to dotenv, json or yaml files.`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/project_level_variables.html#list-project-variables
+type variablesListFlags struct {
+	Id *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+}
+
+var variablesListCmd = &golabCommand{
+	Parent: variablesCmd.Cmd,
+	Flags:  &variablesListFlags{},
+	Opts:   &gitlab.ListBuildVariablesOptions{},
+	Paged:  true,
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List project variables",
+		Long:  `Get list of a project's variables.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*variablesListFlags)
+		opts := cmd.Opts.(*gitlab.ListBuildVariablesOptions)
+		return cmd.outputPaged(func() (interface{}, *gitlab.Response, error) {
+			var variables []*variable
+			resp, err := gitlabRequest("GET", projectPath(*flags.Id, "/variables"), opts, &variables)
+			return variables, resp, err
+		})
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/project_level_variables.html#show-variable-details
+type variablesGetFlags struct {
+	Id  *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Key *string `flag_name:"key" short:"k" type:"string" required:"yes" description:"The key of a variable"`
+}
+
+var variablesGetCmd = &golabCommand{
+	Parent: variablesCmd.Cmd,
+	Flags:  &variablesGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Show variable details",
+		Long:  `Get the details of a project's specific variable.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*variablesGetFlags)
+		v := &variable{}
+		_, err := gitlabRequest("GET", projectPath(*flags.Id, "/variables/%s", url.PathEscape(*flags.Key)), nil, v)
+		if err != nil {
+			return err
+		}
+		return OutputJson(v)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/project_level_variables.html#create-variable
+type variablesSetFlags struct {
+	Id        *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Key       *string `flag_name:"key" short:"k" type:"string" required:"yes" description:"The key of a variable; must have no more than 255 characters; only A-Z, a-z, 0-9, and _ are allowed"`
+	Value     *string `flag_name:"value" short:"v" type:"string" required:"no" description:"The value of a variable"`
+	ValueFile *string `flag_name:"value_file" short:"f" type:"string" required:"no" description:"Local file to read the value from, '-' reads from stdin"`
+	Protected *bool   `flag_name:"protected" short:"p" type:"boolean" required:"no" description:"Whether the variable is protected"`
+	Masked    *bool   `flag_name:"masked" short:"m" type:"boolean" required:"no" description:"Whether the variable is masked"`
+}
+
+var variablesSetCmd = &golabCommand{
+	Parent: variablesCmd.Cmd,
+	Flags:  &variablesSetFlags{},
+	Opts:   &variableOptions{},
+	Cmd: &cobra.Command{
+		Use:   "set",
+		Short: "Create or update variable",
+		Long:  `Create a new variable or update the variable if a variable with the given key already exists.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*variablesSetFlags)
+		opts := cmd.Opts.(*variableOptions)
+		value, err := contentFromFlags(flags.Value, flags.ValueFile, "value", "value_file")
+		if err != nil {
+			return err
+		}
+		opts.Value = &value
+		v := &variable{}
+		resp, err := gitlabRequest("PUT", projectPath(*flags.Id, "/variables/%s", url.PathEscape(*flags.Key)), opts, v)
+		if resp != nil && resp.StatusCode == 404 { // 404 means "Not Found" --> does not exist yet
+			_, err = gitlabRequest("POST", projectPath(*flags.Id, "/variables"), opts, v)
+		}
+		if err != nil {
+			return err
+		}
+		return OutputJson(v)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/project_level_variables.html#remove-variable
+type variablesRemoveFlags struct {
+	Id  *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Key *string `flag_name:"key" short:"k" type:"string" required:"yes" description:"The key of a variable"`
+}
+
+var variablesRemoveCmd = &golabCommand{
+	Parent: variablesCmd.Cmd,
+	Flags:  &variablesRemoveFlags{},
+	Cmd: &cobra.Command{
+		Use:     "rm",
+		Aliases: []string{"delete"},
+		Short:   "Remove variable",
+		Long:    `Remove a project's variable.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*variablesRemoveFlags)
+		_, err := gitlabRequest("DELETE", projectPath(*flags.Id, "/variables/%s", url.PathEscape(*flags.Key)), nil, nil)
+		return err
+	},
+}
+
+type variablesImportFlags struct {
+	Id        *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	File      *string `flag_name:"file" short:"f" type:"string" required:"yes" description:"File to import the variables from, '-' reads from stdin"`
+	Format    *string `flag_name:"format" type:"string" required:"no" description:"Format of the file, one of dotenv, json or yaml (default dotenv)"`
+	Protected *bool   `flag_name:"protected" short:"p" type:"boolean" required:"no" description:"Set protected on all imported variables"`
+	Masked    *bool   `flag_name:"masked" short:"m" type:"boolean" required:"no" description:"Set masked on all imported variables"`
+	Prune     *bool   `flag_name:"prune" type:"boolean" required:"no" description:"Remove variables from the project that are not in the file"`
+}
+
+var variablesImportCmd = &golabCommand{
+	Parent: variablesCmd.Cmd,
+	Flags:  &variablesImportFlags{},
+	Cmd: &cobra.Command{
+		Use:   "import",
+		Short: "Import variables from a file",
+		Long: `Import variables from a dotenv, json or yaml file (as written by 'variables export').
+
+Variables that do not exist are created, variables whose value or flags differ are updated. Variables of the project that are not in the file are only removed if --prune is given.
+Protected and masked flags of existing variables are kept unless they are given in the file or with --protected / --masked.
+
+Example:
+
+	golab variables export -i group/source > .env
+	golab variables import -i group/target -f .env --prune`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*variablesImportFlags)
+		content, err := readFileOrStdin(*flags.File)
+		if err != nil {
+			return err
+		}
+		vars, err := parseVariables(content, variablesFormat(flags.Format))
+		if err != nil {
+			return err
+		}
+		for _, v := range vars {
+			if flags.Protected != nil {
+				v.Protected = flags.Protected
+			}
+			if flags.Masked != nil {
+				v.Masked = flags.Masked
+			}
+		}
+		return syncVariables(*flags.Id, vars, flags.Prune != nil && *flags.Prune)
+	},
+}
+
+type variablesExportFlags struct {
+	Id     *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project owned by the authenticated user"`
+	Format *string `flag_name:"format" type:"string" required:"no" description:"Format of the output, one of dotenv, json or yaml (default dotenv)"`
+}
+
+var variablesExportCmd = &golabCommand{
+	Parent: variablesCmd.Cmd,
+	Flags:  &variablesExportFlags{},
+	Cmd: &cobra.Command{
+		Use:   "export",
+		Short: "Export variables to a file",
+		Long:  `Print all variables of a project as dotenv, json or yaml. Only json and yaml contain the protected and masked flags.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*variablesExportFlags)
+		vars, err := listAllVariables(*flags.Id)
+		if err != nil {
+			return err
+		}
+		out, err := formatVariables(vars, variablesFormat(flags.Format))
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(os.Stdout, out)
+		return nil
+	},
+}
+
+func variablesFormat(format *string) string {
+	if format == nil {
+		return "dotenv"
+	}
+	return *format
+}
+
+// listAllVariables returns the variables of a project from all result pages
+func listAllVariables(pid string) ([]*variable, error) {
+	var variables []*variable
+	opts := &gitlab.ListBuildVariablesOptions{}
+	err := forEachPage(&opts.ListOptions, func() (*gitlab.Response, error) {
+		var page []*variable
+		resp, err := gitlabRequest("GET", projectPath(pid, "/variables"), opts, &page)
+		variables = append(variables, page...)
+		return resp, err
+	})
+	return variables, err
+}
+
+// syncVariables creates missing and updates changed variables, variables
+// that are not in vars are removed if prune is true
+func syncVariables(pid string, vars []*variableOptions, prune bool) error {
+	existing, err := listAllVariables(pid)
+	if err != nil {
+		return err
+	}
+	existingByKey := map[string]*variable{}
+	for _, v := range existing {
+		existingByKey[v.Key] = v
+	}
+	keys := map[string]bool{}
+	for _, v := range vars {
+		keys[*v.Key] = true
+		current, ok := existingByKey[*v.Key]
+		if !ok {
+			if _, err := gitlabRequest("POST", projectPath(pid, "/variables"), v, nil); err != nil {
+				return err
+			}
+			fmt.Println("created " + *v.Key)
+		} else if variableChanged(current, v) {
+			if _, err := gitlabRequest("PUT", projectPath(pid, "/variables/%s", url.PathEscape(*v.Key)), v, nil); err != nil {
+				return err
+			}
+			fmt.Println("updated " + *v.Key)
+		}
+	}
+	if !prune {
+		return nil
+	}
+	for _, v := range existing {
+		if keys[v.Key] {
+			continue
+		}
+		if _, err := gitlabRequest("DELETE", projectPath(pid, "/variables/%s", url.PathEscape(v.Key)), nil, nil); err != nil {
+			return err
+		}
+		fmt.Println("removed " + v.Key)
+	}
+	return nil
+}
+
+func variableChanged(current *variable, v *variableOptions) bool {
+	return (v.Value != nil && *v.Value != current.Value) ||
+		(v.Protected != nil && *v.Protected != current.Protected) ||
+		(v.Masked != nil && *v.Masked != current.Masked)
+}
+
+// parseVariables reads variables from content in the given format, each
+// variable has a key
+func parseVariables(content string, format string) ([]*variableOptions, error) {
+	var vars []*variableOptions
+	var err error
+	switch format {
+	case "dotenv":
+		vars, err = parseDotenv(content)
+	case "json":
+		err = json.Unmarshal([]byte(content), &vars)
+	case "yaml":
+		err = yaml.Unmarshal([]byte(content), &vars)
+	default:
+		return nil, errors.New("unknown format " + format + ", must be one of dotenv, json or yaml")
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range vars {
+		if v.Key == nil || *v.Key == "" {
+			return nil, fmt.Errorf("variable %d has no key", i+1)
+		}
+	}
+	return vars, nil
+}
+
+func formatVariables(vars []*variable, format string) (string, error) {
+	switch format {
+	case "dotenv":
+		return formatDotenv(vars), nil
+	case "json":
+		out, err := json.MarshalIndent(vars, "", "  ")
+		return string(out) + "\n", err
+	case "yaml":
+		out, err := yaml.Marshal(vars)
+		return string(out), err
+	}
+	return "", errors.New("unknown format " + format + ", must be one of dotenv, json or yaml")
+}
+
+// parseDotenv parses lines of KEY=VALUE, optionally prefixed by 'export'.
+// Values can be single quoted (taken literally) or double quoted (with Go
+// escape sequences like \n), empty lines and lines starting with # are skipped.
+func parseDotenv(content string) ([]*variableOptions, error) {
+	var vars []*variableOptions
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", i+1)
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		switch {
+		case strings.HasPrefix(value, `"`):
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value", i+1)
+			}
+			value = unquoted
+		case strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) > 1:
+			value = value[1 : len(value)-1]
+		default:
+			if comment := strings.Index(value, " #"); comment >= 0 {
+				value = strings.TrimSpace(value[:comment])
+			}
+		}
+		vars = append(vars, &variableOptions{Key: gitlab.String(key), Value: gitlab.String(value)})
+	}
+	return vars, nil
+}
+
+var unquotedDotenvValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,-]*$`)
+
+// formatDotenv prints variables as KEY=VALUE lines sorted by key, values
+// with special characters are double quoted
+func formatDotenv(vars []*variable) string {
+	sorted := make([]*variable, len(vars))
+	copy(sorted, vars)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	var out strings.Builder
+	for _, v := range sorted {
+		value := v.Value
+		if !unquotedDotenvValue.MatchString(value) {
+			value = strconv.Quote(value)
+		}
+		out.WriteString(v.Key + "=" + value + "\n")
+	}
+	return out.String()
+}
+
+func init() {
+	variablesCmd.Init()
+	variablesListCmd.Init()
+	variablesGetCmd.Init()
+	variablesSetCmd.Init()
+	variablesRemoveCmd.Init()
+	variablesImportCmd.Init()
+	variablesExportCmd.Init()
+	AddDefaultColumns(variable{}, "key", "value", "protected", "masked")
+}
@@ -0,0 +1,98 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("variables command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `import` sub command is executed with `--prune`", func() {
+		It("creates, updates and removes variables", func() {
+			var requests []string
+			mux.HandleFunc("/api/v4/projects/1/variables", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					fmt.Fprint(w, `[{"key":"SAME","value":"1"},{"key":"CHANGED","value":"old","protected":true},{"key":"EXTRA","value":"x"}]`)
+					return
+				}
+				var body map[string]interface{}
+				json.NewDecoder(r.Body).Decode(&body)
+				requests = append(requests, r.Method+" "+body["key"].(string))
+				fmt.Fprint(w, `{}`)
+			})
+			mux.HandleFunc("/api/v4/projects/1/variables/", func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				fmt.Fprint(w, `{}`)
+			})
+			stdin := os.Stdin
+			defer func() { os.Stdin = stdin }()
+			r, w, _ := os.Pipe()
+			w.WriteString("# comment\nSAME=1\nexport CHANGED=\"new value\\n\"\nNEW='a b'\n")
+			w.Close()
+			os.Stdin = r
+
+			out, _, err := executeCommand(RootCmd, "variables", "import", "-i", "1", "-f", "-", "--prune")
+			Expect(err).To(BeNil())
+			Expect(requests).To(Equal([]string{
+				"PUT /api/v4/projects/1/variables/CHANGED",
+				"POST NEW",
+				"DELETE /api/v4/projects/1/variables/EXTRA",
+			}))
+			Expect(out).To(Equal("updated CHANGED\ncreated NEW\nremoved EXTRA"))
+		})
+	})
+})
+
+var _ = Describe("formatDotenv", func() {
+	It("quotes values with special characters so that parseDotenv reads them back", func() {
+		vars := []*variable{{Key: "B", Value: "multi\nline \"quoted\""}, {Key: "A", Value: "https://example.com/x"}}
+		out := formatDotenv(vars)
+		Expect(out).To(Equal("A=https://example.com/x\nB=\"multi\\nline \\\"quoted\\\"\"\n"))
+		parsed, err := parseDotenv(out)
+		Expect(err).To(BeNil())
+		Expect(*parsed[1].Value).To(Equal(vars[0].Value))
+	})
+})
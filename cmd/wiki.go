@@ -0,0 +1,339 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	. "github.com/michaellihs/golab/cmd/helpers"
+
+	"github.com/spf13/cobra"
+	"github.com/xanzy/go-gitlab"
+)
+
+// see https://docs.gitlab.com/ce/api/wikis.html
+var wikiCmd = &golabCommand{
+	Parent: RootCmd,
+	Cmd: &cobra.Command{
+		Use:     "wiki",
+		Aliases: []string{"wikis"},
+		Short:   "Manage wiki pages",
+		Long:    `List, create, edit and delete the wiki pages of a project and sync them from a local directory`,
+	},
+	Run: func(cmd golabCommand) error {
+		return errors.New("cannot use this command without further sub-commands")
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/wikis.html#list-wiki-pages
+type wikiListFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project"`
+	WithContent *bool   `flag_name:"with_content" short:"c" type:"boolean" required:"no" description:"Include pages' content"`
+}
+
+var wikiListCmd = &golabCommand{
+	Parent: wikiCmd.Cmd,
+	Flags:  &wikiListFlags{},
+	Opts:   &gitlab.ListWikisOptions{},
+	Cmd: &cobra.Command{
+		Use:   "ls",
+		Short: "List wiki pages",
+		Long:  `Get all wiki pages for a given project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*wikiListFlags)
+		opts := cmd.Opts.(*gitlab.ListWikisOptions)
+		pages, _, err := gitlabClient.Wikis.ListWikis(*flags.Id, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(pages)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/wikis.html#get-a-wiki-page
+type wikiGetFlags struct {
+	Id   *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project"`
+	Slug *string `flag_name:"slug" short:"s" type:"string" required:"yes" description:"The slug (a unique string) of the wiki page"`
+}
+
+var wikiGetCmd = &golabCommand{
+	Parent: wikiCmd.Cmd,
+	Flags:  &wikiGetFlags{},
+	Cmd: &cobra.Command{
+		Use:   "get",
+		Short: "Get a wiki page",
+		Long:  `Get a wiki page for a given project.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*wikiGetFlags)
+		page, _, err := gitlabClient.Wikis.GetWikiPage(*flags.Id, *flags.Slug)
+		if err != nil {
+			return err
+		}
+		return OutputJson(page)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/wikis.html#create-a-new-wiki-page
+type wikiCreateFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project"`
+	Title       *string `flag_name:"title" short:"t" type:"string" required:"yes" description:"The title of the wiki page"`
+	Content     *string `flag_name:"content" short:"c" type:"string" required:"no" description:"The content of the wiki page"`
+	ContentFile *string `flag_name:"content_file" short:"f" type:"string" required:"no" description:"Local file to read the content of the wiki page from, '-' reads from stdin"`
+	Format      *string `flag_name:"format" type:"string" required:"no" description:"The format of the wiki page. Available formats are: markdown (default), rdoc, and asciidoc"`
+}
+
+var wikiCreateCmd = &golabCommand{
+	Parent: wikiCmd.Cmd,
+	Flags:  &wikiCreateFlags{},
+	Opts:   &gitlab.CreateWikiPageOptions{},
+	Cmd: &cobra.Command{
+		Use:   "create",
+		Short: "Create a new wiki page",
+		Long:  `Creates a new wiki page for the given repository with the given title, slug, and content.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*wikiCreateFlags)
+		opts := cmd.Opts.(*gitlab.CreateWikiPageOptions)
+		content, err := contentFromFlags(flags.Content, flags.ContentFile, "content", "content_file")
+		if err != nil {
+			return err
+		}
+		opts.Content = &content
+		page, _, err := gitlabClient.Wikis.CreateWikiPage(*flags.Id, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(page)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/wikis.html#edit-an-existing-wiki-page
+type wikiEditFlags struct {
+	Id          *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project"`
+	Slug        *string `flag_name:"slug" short:"s" type:"string" required:"yes" description:"The slug (a unique string) of the wiki page"`
+	Title       *string `flag_name:"title" short:"t" type:"string" required:"no" description:"The title of the wiki page"`
+	Content     *string `flag_name:"content" short:"c" type:"string" required:"no" description:"The content of the wiki page"`
+	ContentFile *string `flag_name:"content_file" short:"f" type:"string" required:"no" description:"Local file to read the content of the wiki page from, '-' reads from stdin"`
+	Format      *string `flag_name:"format" type:"string" required:"no" description:"The format of the wiki page. Available formats are: markdown (default), rdoc, and asciidoc"`
+}
+
+var wikiEditCmd = &golabCommand{
+	Parent: wikiCmd.Cmd,
+	Flags:  &wikiEditFlags{},
+	Opts:   &gitlab.EditWikiPageOptions{},
+	Cmd: &cobra.Command{
+		Use:   "edit",
+		Short: "Edit an existing wiki page",
+		Long:  `Updates an existing wiki page. Title and content are kept if they are not given.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*wikiEditFlags)
+		opts := cmd.Opts.(*gitlab.EditWikiPageOptions)
+		if flags.Content != nil || flags.ContentFile != nil {
+			content, err := contentFromFlags(flags.Content, flags.ContentFile, "content", "content_file")
+			if err != nil {
+				return err
+			}
+			opts.Content = &content
+		}
+		if opts.Title == nil || opts.Content == nil {
+			// the API requires both title and content
+			current, _, err := gitlabClient.Wikis.GetWikiPage(*flags.Id, *flags.Slug)
+			if err != nil {
+				return err
+			}
+			if opts.Title == nil {
+				opts.Title = &current.Title
+			}
+			if opts.Content == nil {
+				opts.Content = &current.Content
+			}
+		}
+		page, _, err := gitlabClient.Wikis.EditWikiPage(*flags.Id, *flags.Slug, opts)
+		if err != nil {
+			return err
+		}
+		return OutputJson(page)
+	},
+}
+
+// see https://docs.gitlab.com/ce/api/wikis.html#delete-a-wiki-page
+type wikiDeleteFlags struct {
+	Id   *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project"`
+	Slug *string `flag_name:"slug" short:"s" type:"string" required:"yes" description:"The slug (a unique string) of the wiki page"`
+}
+
+var wikiDeleteCmd = &golabCommand{
+	Parent: wikiCmd.Cmd,
+	Flags:  &wikiDeleteFlags{},
+	Cmd: &cobra.Command{
+		Use:   "delete",
+		Short: "Delete a wiki page",
+		Long:  `Deletes a wiki page with a given slug.`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*wikiDeleteFlags)
+		_, err := gitlabClient.Wikis.DeleteWikiPage(*flags.Id, *flags.Slug)
+		return err
+	},
+}
+
+type wikiSyncFlags struct {
+	Id     *string `flag_name:"id" short:"i" type:"integer/string" required:"yes" description:"The ID or URL-encoded path of the project"`
+	Dir    *string `flag_name:"dir" short:"d" type:"string" required:"yes" description:"Local directory with the markdown files of the wiki"`
+	Prune  *bool   `flag_name:"prune" type:"boolean" required:"no" description:"Delete wiki pages that do not exist in the local directory"`
+	DryRun *bool   `flag_name:"dry_run" type:"boolean" required:"no" description:"Only print the pages that would be created, updated or deleted"`
+}
+
+var wikiSyncCmd = &golabCommand{
+	Parent: wikiCmd.Cmd,
+	Flags:  &wikiSyncFlags{},
+	Cmd: &cobra.Command{
+		Use:   "sync",
+		Short: "Sync wiki pages from a local directory",
+		Long: `Mirror the markdown files (*.md) of a local directory into the wiki of a project.
+
+The path of a file relative to the directory without extension is the title of its page, e.g. runbooks/database.md becomes the page runbooks/database.
+Pages that do not exist are created, pages whose content differs are updated. Pages without local file are only deleted if --prune is given.
+
+Example:
+
+    golab wiki sync -i my-group/operations -d docs/ --prune`,
+	},
+	Run: func(cmd golabCommand) error {
+		flags := cmd.Flags.(*wikiSyncFlags)
+		pages, err := readWikiDir(*flags.Dir)
+		if err != nil {
+			return err
+		}
+		return syncWiki(*flags.Id, pages, flags.Prune != nil && *flags.Prune, flags.DryRun != nil && *flags.DryRun)
+	},
+}
+
+// readWikiDir returns the markdown files in dir as wiki pages, sorted by title
+func readWikiDir(dir string) ([]*gitlab.Wiki, error) {
+	var pages []*gitlab.Wiki
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(path) != ".md" {
+			return nil
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		title := strings.TrimSuffix(filepath.ToSlash(rel), ".md")
+		pages = append(pages, &gitlab.Wiki{
+			Title:   title,
+			Slug:    strings.Replace(title, " ", "-", -1),
+			Content: string(content),
+			Format:  gitlab.WikiFormatMarkdown,
+		})
+		return nil
+	})
+	sort.Slice(pages, func(i, j int) bool { return pages[i].Title < pages[j].Title })
+	return pages, err
+}
+
+// syncWiki creates missing and updates changed wiki pages, pages that are
+// not in pages are deleted if prune is true
+func syncWiki(pid string, pages []*gitlab.Wiki, prune bool, dryRun bool) error {
+	existing, _, err := gitlabClient.Wikis.ListWikis(pid, &gitlab.ListWikisOptions{WithContent: gitlab.Bool(true)})
+	if err != nil {
+		return err
+	}
+	existingBySlug := map[string]*gitlab.Wiki{}
+	for _, page := range existing {
+		existingBySlug[page.Slug] = page
+	}
+	slugs := map[string]bool{}
+	for _, page := range pages {
+		slugs[page.Slug] = true
+		current, ok := existingBySlug[page.Slug]
+		format := string(page.Format)
+		if !ok {
+			if !dryRun {
+				opts := &gitlab.CreateWikiPageOptions{Title: &page.Title, Content: &page.Content, Format: &format}
+				if _, _, err := gitlabClient.Wikis.CreateWikiPage(pid, opts); err != nil {
+					return err
+				}
+			}
+			printSyncAction(dryRun, "create", page.Slug)
+		} else if strings.TrimSpace(current.Content) != strings.TrimSpace(page.Content) {
+			if !dryRun {
+				opts := &gitlab.EditWikiPageOptions{Title: &page.Title, Content: &page.Content, Format: &format}
+				if _, _, err := gitlabClient.Wikis.EditWikiPage(pid, page.Slug, opts); err != nil {
+					return err
+				}
+			}
+			printSyncAction(dryRun, "update", page.Slug)
+		}
+	}
+	if !prune {
+		return nil
+	}
+	for _, page := range existing {
+		if slugs[page.Slug] {
+			continue
+		}
+		if !dryRun {
+			if _, err := gitlabClient.Wikis.DeleteWikiPage(pid, page.Slug); err != nil {
+				return err
+			}
+		}
+		printSyncAction(dryRun, "delete", page.Slug)
+	}
+	return nil
+}
+
+// printSyncAction prints e.g. "created page" or, for dry runs, "would create page"
+func printSyncAction(dryRun bool, action string, name string) {
+	if dryRun {
+		fmt.Println("would " + action + " " + name)
+	} else {
+		fmt.Println(action + "d " + name)
+	}
+}
+
+func init() {
+	wikiCmd.Init()
+	wikiListCmd.Init()
+	wikiGetCmd.Init()
+	wikiCreateCmd.Init()
+	wikiEditCmd.Init()
+	wikiDeleteCmd.Init()
+	wikiSyncCmd.Init()
+	AddDefaultColumns(gitlab.Wiki{}, "slug", "title", "format")
+}
@@ -0,0 +1,102 @@
+// Copyright © 2018 Michael Lihs
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/xanzy/go-gitlab"
+)
+
+var _ = Describe("wiki command", func() {
+
+	var (
+		mux    *http.ServeMux
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		resetCommandLineFlagSet()
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		gitlabClient = gitlab.NewClient(nil, "")
+		gitlabClient.SetBaseURL(server.URL + "/api/v4")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+	Context("when the `sync` sub command is executed", func() {
+		var (
+			dir      string
+			requests []string
+		)
+
+		BeforeEach(func() {
+			requests = nil
+			dir, _ = ioutil.TempDir("", "wiki")
+			os.MkdirAll(filepath.Join(dir, "runbooks"), 0755)
+			ioutil.WriteFile(filepath.Join(dir, "home.md"), []byte("Welcome\n"), 0644)
+			ioutil.WriteFile(filepath.Join(dir, "runbooks", "database.md"), []byte("Restart it"), 0644)
+			ioutil.WriteFile(filepath.Join(dir, "runbooks", "notes.txt"), []byte("ignored"), 0644)
+			mux.HandleFunc("/api/v4/projects/1/wikis", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "GET" {
+					fmt.Fprint(w, `[{"slug":"home","title":"home","content":"Welcome"},{"slug":"runbooks/database","title":"runbooks/database","content":"Reboot it"},{"slug":"obsolete","title":"obsolete","content":"x"}]`)
+					return
+				}
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				fmt.Fprint(w, `{}`)
+			})
+			mux.HandleFunc("/api/v4/projects/1/wikis/", func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+				fmt.Fprint(w, `{}`)
+			})
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("updates changed pages and deletes removed pages with `--prune`", func() {
+			out, _, err := executeCommand(RootCmd, "wiki", "sync", "-i", "1", "-d", dir, "--prune")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("updated runbooks/database\ndeleted obsolete"))
+			Expect(requests).To(Equal([]string{
+				"PUT /api/v4/projects/1/wikis/runbooks%2Fdatabase",
+				"DELETE /api/v4/projects/1/wikis/obsolete",
+			}))
+		})
+
+		It("does not change the wiki with `--dry_run`", func() {
+			out, _, err := executeCommand(RootCmd, "wiki", "sync", "-i", "1", "-d", dir, "--prune", "--dry_run")
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("would update runbooks/database\nwould delete obsolete"))
+			Expect(requests).To(BeEmpty())
+		})
+	})
+})